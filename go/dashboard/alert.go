@@ -0,0 +1,176 @@
+package dashboard
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Alert rule kinds
+const (
+	AlertAbove = iota // numeric value greater than Limit
+	AlertBelow        // numeric value less than Limit
+	AlertMatch        // value matched by the regular expression Pattern
+	AlertStale        // value unchanged for at least Age
+)
+
+const (
+	cnAlertPeriod = 250 * time.Millisecond // interval between checks of stale values and flashes
+	cnFlashCount  = 8                      // number of alert periods a triggered field flashes
+)
+
+// AlertType describes a rule that is applied to the value of a key/value
+// field. Kind is one of AlertAbove, AlertBelow, AlertMatch or AlertStale, and
+// determines which of Limit, Pattern or Age applies; values that are not
+// numbers never satisfy AlertAbove or AlertBelow. While the rule holds, the
+// value is shown with Style (StyleWarn, for example) in place of the style
+// given with the update. When the rule starts to hold, the field flashes for
+// two seconds if Flash is set and the terminal bell rings if Bell is set. If a
+// rolling line field has been designated with SetAlertLine(), an entry with
+// the severity Level is added to it. Headless dashboards only add the entry.
+type AlertType struct {
+	Kind    int
+	Limit   float64
+	Pattern string
+	Age     time.Duration
+	Style   int
+	Flash   bool
+	Bell    bool
+	Level   int
+}
+
+// alertType is an alert rule attached to a field.
+type alertType struct {
+	AlertType
+	re     *regexp.Regexp // compiled Pattern for AlertMatch
+	active bool           // rule held at the last check
+}
+
+// alertHolds returns true if the rule specified by al applies to the
+// key/value field specified by fld at the time specified by tm.
+func alertHolds(al *alertType, fld fieldPtrType, tm time.Time) bool {
+	if fld.count == 0 {
+		return false
+	}
+	switch al.Kind {
+	case AlertAbove, AlertBelow:
+		val, err := strconv.ParseFloat(strings.TrimSpace(fld.valStr), 64)
+		if err == nil {
+			if al.Kind == AlertAbove {
+				return val > al.Limit
+			}
+			return val < al.Limit
+		}
+	case AlertMatch:
+		return al.re.MatchString(fld.valStr)
+	case AlertStale:
+		return tm.Sub(fld.changeTm) >= al.Age
+	}
+	return false
+}
+
+// alertStr returns a description of the triggered rule specified by al for
+// the alert line.
+func alertStr(al *alertType, fld fieldPtrType) string {
+	switch al.Kind {
+	case AlertAbove:
+		return fmt.Sprintf("%s %s above %g", fld.str, fld.valStr, al.Limit)
+	case AlertBelow:
+		return fmt.Sprintf("%s %s below %g", fld.str, fld.valStr, al.Limit)
+	case AlertMatch:
+		return fmt.Sprintf("%s %s matches %s", fld.str, fld.valStr, al.Pattern)
+	}
+	return fmt.Sprintf("%s %s unchanged for %s", fld.str, fld.valStr, al.Age)
+}
+
+// alertCheck applies the rules of the key/value field specified by fld at the
+// time specified by tm. Rules that have started to hold sound the bell, start
+// a flash and add an entry to the alert line. The style of the first rule
+// that holds overrides the style of the value. changed is true if the field
+// needs to be drawn again; lineFld is the alert line field if an entry was
+// added to it, otherwise nil.
+func (dsh *Dashboard) alertCheck(fld fieldPtrType, tm time.Time) (changed bool, lineFld fieldPtrType) {
+	alerted := false
+	style := StyleValue
+	for _, al := range fld.alertList {
+		holds := alertHolds(al, fld, tm)
+		if holds && !al.active {
+			// Headless dashboards only report alerts on the alert line
+			if al.Bell && dsh.out == nil {
+				dsh.screen.Beep()
+			}
+			if al.Flash && dsh.out == nil {
+				fld.flash = cnFlashCount
+			}
+			if dsh.alertLineOK {
+				dsh.fieldMtx.Lock()
+				fieldPtr, ok := dsh.fieldMap[dsh.alertLineID]
+				dsh.fieldMtx.Unlock()
+				if ok && fieldPtr.item == itemLine {
+					lineAdd(fieldPtr, lineRecType{tm: tm, level: al.Level, msgStr: alertStr(al, fld)})
+					lineFld = fieldPtr
+				}
+			}
+		}
+		al.active = holds
+		if holds && !alerted {
+			alerted = true
+			style = al.Style
+		}
+	}
+	if alerted != fld.alerted || style != fld.alertStyle {
+		fld.alerted = alerted
+		fld.alertStyle = style
+		changed = true
+	}
+	return
+}
+
+// AddAlert attaches the rule specified by alert to the key/value field
+// specified by id. A field may have several rules; the first one that holds
+// determines the style of the value. An error is returned if alert.Pattern is
+// not a valid regular expression.
+func (dsh *Dashboard) AddAlert(id int, alert AlertType) (err error) {
+	al := &alertType{AlertType: alert}
+	if alert.Kind == AlertMatch {
+		al.re, err = regexp.Compile(alert.Pattern)
+	}
+	if err == nil {
+		dsh.control(updateType{id: updateAlert, target: id, alert: al})
+	}
+	return
+}
+
+// ClearAlerts removes the rules attached to the key/value field specified by
+// id.
+func (dsh *Dashboard) ClearAlerts(id int) {
+	dsh.control(updateType{id: updateAlert, target: id})
+}
+
+// SetAlertLine designates the rolling line field specified by id to receive
+// an entry each time an alert rule starts to hold. This method must be called
+// before Run().
+func (dsh *Dashboard) SetAlertLine(id int) {
+	dsh.alertLineID = id
+	dsh.alertLineOK = true
+}
+
+// AddAlert attaches an alert rule to a key/value field of the default
+// dashboard. See Dashboard.AddAlert() for details.
+func AddAlert(id int, alert AlertType) error {
+	return std.AddAlert(id, alert)
+}
+
+// ClearAlerts removes the alert rules of a key/value field of the default
+// dashboard.
+func ClearAlerts(id int) {
+	std.ClearAlerts(id)
+}
+
+// SetAlertLine designates the rolling line field of the default dashboard
+// that receives alert entries. See Dashboard.SetAlertLine() for details.
+func SetAlertLine(id int) {
+	std.SetAlertLine(id)
+}
@@ -0,0 +1,143 @@
+package dashboard
+
+import (
+	"github.com/gdamore/tcell"
+	"github.com/jung-kurt/etc/go/util"
+)
+
+// eighths holds the block runes used to show a fraction of a chart cell
+var eighths = [8]rune{'▁', '▂', '▃', '▄', '▅', '▆', '▇', '█'}
+
+func f3(val float64) string {
+	return util.Float64ToStrSig(val, ".", ",", 3, 3)
+}
+
+// chartAdd appends the sample specified by val to the ring buffer of the
+// chart field specified by fld, discarding the oldest sample if the buffer is
+// full.
+func chartAdd(fld fieldPtrType, val float64) {
+	size := len(fld.valList)
+	if fld.count < size {
+		fld.count++
+	}
+	fld.valList[fld.pos] = val
+	fld.pos++
+	if fld.pos >= size {
+		fld.pos = 0
+	}
+}
+
+// chartSample returns the sample at position j of the chart field specified
+// by fld, where zero is the oldest retained sample.
+func chartSample(fld fieldPtrType, j int) float64 {
+	k := fld.pos + j
+	if k >= fld.count {
+		k -= fld.count
+	}
+	return fld.valList[k]
+}
+
+// chartPut draws the chart field specified by fld. The first row shows the
+// static key followed by the minimum, maximum and most recent sample values.
+// The remaining fld.ht rows show the most recent samples, one per column with
+// the newest at the right, scaled between the minimum and maximum of the
+// displayed samples.
+func (dsh *Dashboard) chartPut(keySt, valSt tcell.Style, scrWd int, fld fieldPtrType) {
+	wd := fieldWidth(fld, scrWd)
+	if wd > cnMaxWidth {
+		wd = cnMaxWidth
+	}
+	if wd <= 0 || fld.count == 0 {
+		return
+	}
+	first := 0
+	if fld.count > wd {
+		first = fld.count - wd
+	}
+	lo := chartSample(fld, first)
+	hi := lo
+	for j := first; j < fld.count; j++ {
+		val := chartSample(fld, j)
+		if val < lo {
+			lo = val
+		}
+		if val > hi {
+			hi = val
+		}
+	}
+	last := chartSample(fld, fld.count-1)
+	lim := fld.x + wd
+	if lim > scrWd {
+		lim = scrWd
+	}
+	x := dsh.put(keySt, fld.x, fld.y, lim, fld.str, " min ")
+	x = dsh.put(valSt, x, fld.y, lim, f3(lo))
+	x = dsh.put(keySt, x, fld.y, lim, " max ")
+	x = dsh.put(valSt, x, fld.y, lim, f3(hi))
+	x = dsh.put(keySt, x, fld.y, lim, " last ")
+	x = dsh.put(valSt, x, fld.y, lim, f3(last))
+	if x < lim {
+		dsh.put(keySt, x, fld.y, lim, blankStr[:lim-x])
+	}
+	// Each column is scaled to a whole number of eighths; at least one eighth
+	// is shown so that the minimum value remains visible.
+	span := fld.ht * 8
+	var row [cnMaxWidth]rune
+	lead := wd - (fld.count - first)
+	for r := 0; r < fld.ht; r++ {
+		base := (fld.ht - 1 - r) * 8
+		for c := 0; c < wd; c++ {
+			row[c] = ' '
+			if c >= lead {
+				level := span / 2
+				if hi > lo {
+					level = 1 + int((chartSample(fld, first+c-lead)-lo)/(hi-lo)*float64(span-1)+0.5)
+				}
+				level -= base
+				if level > 8 {
+					level = 8
+				}
+				if level > 0 {
+					row[c] = eighths[level-1]
+				}
+			}
+		}
+		dsh.put(valSt, fld.x, fld.y+1+r, lim, string(row[:wd]))
+	}
+}
+
+// RegisterChart registers a dashboard time-series chart with the identifier
+// specified by id. Its coordinates are specified by x and y. The total field's
+// width is specified by wd; a zero value indicates the full width of the
+// screen, and a negative value indicates the position from the right. The
+// first row shows the static key specified by keyStr followed by the minimum,
+// maximum and most recent values of the retained samples. The chart itself
+// occupies the ht rows below it; a value of 1 produces a sparkline. Up to
+// count of the most recent samples are retained.
+func (dsh *Dashboard) RegisterChart(id, x, y, wd, ht, count int, keyStr string) {
+	if ht < 1 {
+		ht = 1
+	}
+	if count < 1 {
+		count = 1
+	}
+	dsh.fieldRegister(id, &fieldType{id: id, item: itemChart, x: x, y: y, wd: wd,
+		ht: ht, str: keyStr, valList: make([]float64, count)})
+}
+
+// UpdateChart appends the sample specified by val to the chart specified by
+// id.
+func (dsh *Dashboard) UpdateChart(id int, val float64) {
+	dsh.send(updateType{id: id, val: val})
+}
+
+// RegisterChart registers a time-series chart with the default dashboard. See
+// Dashboard.RegisterChart() for details.
+func RegisterChart(id, x, y, wd, ht, count int, keyStr string) {
+	std.RegisterChart(id, x, y, wd, ht, count, keyStr)
+}
+
+// UpdateChart appends a sample to a chart of the default dashboard.
+func UpdateChart(id int, val float64) {
+	std.UpdateChart(id, val)
+}
@@ -1,38 +1,96 @@
 package dashboard
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"log"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gdamore/tcell"
+	"github.com/jung-kurt/etc/go/util"
+	"github.com/mattn/go-runewidth"
 )
 
-// The update channel is used (with the UpdateXXX() functions as wrappers) to
-// update various dashboard fields. It is ready to receive records as soon as
-// the application is initialized. It is kept open through the termination of
-// the application to prevent panics if the application updates a field after
-// the main dashboard loop has completed.
-
-type screenType struct {
-	dotStr        string               // separation for key/value fields
-	blankStr      string               // overflow blank string to clear characters of previous string
-	diamonds      [cnMaxWidth]rune     // overflow indictor
-	lines         [cnMaxWidth]rune     // line made up of horizontal line runes
+// Dashboard manages a terminal screen made up of fields that are registered
+// and updated by the application. The update channel is used (with the
+// UpdateXXX() methods as wrappers) to update various dashboard fields. It is
+// ready to receive records as soon as the dashboard is created. It is kept
+// open through the termination of the dashboard to prevent panics if the
+// application updates a field after the main dashboard loop has completed. The
+// UpdateXXX() methods never block; if the application outpaces the dashboard
+// and the channel fills, updates are discarded and counted (see
+// RegisterStats()). Control requests, such as Hide(), SetTheme() or Prompt(),
+// are never discarded; they are queued separately without limit and applied in
+// order by the running dashboard. Field values are recorded as they arrive,
+// but the screen is refreshed at most at the frame rate (see SetFrameRate()),
+// so a field updated several times between refreshes is drawn once with its
+// latest value.
+type Dashboard struct {
+	dropped       int64                // number of updates discarded because updateChan was full; accessed atomically
 	buf           *strings.Builder     // formatted string buffer; accessed only by 'dashboard show' goroutine
 	fieldMap      map[int]fieldPtrType // fields registered by application
 	fieldMtx      sync.Mutex           // mutex for accessing fieldMap
 	updateChan    chan updateType      // dashboard updates fields based on events arriving in this channel
+	ctrlList      []updateType         // control requests awaiting the 'dashboard show' goroutine
+	ctrlMtx       sync.Mutex           // mutex for accessing ctrlList
+	ctrlChan      chan struct{}        // signals that ctrlList is not empty
 	screen        tcell.Screen         // terminal screen
 	active        bool                 // update channel is active
 	activeMtx     sync.Mutex           // mutex for accessing the active flag
 	updateable    bool                 // update channel is active
-	updateableMtx sync.Mutex           // mutex for accessing the active flag
+	updateableMtx sync.Mutex           // mutex for accessing the updateable flag
+	cancel        context.CancelFunc   // stops the current run, nil if not running; protected by activeMtx
+	doneChan      chan struct{}        // closed when the current run ends; protected by activeMtx
+	ownScreen     bool                 // screen was created by Run()
+	quitMap       map[rune]bool        // runes that terminate Run(); accessed only by 'dashboard show' goroutine
+	quitList      []rune               // quit runes in the order given to Run()
+	keyList       []keyBindingType     // application key bindings
+	keyMtx        sync.Mutex           // mutex for accessing keyList
+	helpShow      bool                 // help overlay is shown
+	modalList     []*modalType         // prompts and menus, the first of which is shown; accessed only by 'dashboard show' goroutine
+	focusID       int                  // identifier of rolling line field with keyboard focus
+	focusOK       bool                 // a rolling line field has keyboard focus
+	searchEdit    bool                 // search pattern is being entered
+	searchBuf     []rune               // search pattern being entered
+	searchStr     string               // confirmed search pattern, empty for none
+	theme         ThemeType            // styles used to draw fields; accessed only by 'dashboard show' goroutine
+	themeSet      bool                 // theme was assigned with SetTheme() rather than chosen by Run()
+	layout        *LayoutType          // field placement, nil if fields use their registered positions
+	out           io.Writer            // destination of headless snapshots, nil to use screen
+	period        time.Duration        // interval between headless snapshots
+	format        int                  // headless snapshot format, such as FormatText
+	pub           Publisher            // recipient of field updates, nil for none
+	pubCategory   string               // category of published field updates
+	frame         time.Duration        // minimum interval between screen refreshes
+	received      int64                // number of field updates processed; accessed only by 'dashboard show' goroutine
+	frameCount    int                  // number of screen refreshes since statTm
+	statTm        time.Time            // start of the current frame rate measurement
+	frameRate     float64              // screen refreshes per second in the most recent measurement
+	rec           *json.Encoder        // destination of recorded field updates, nil for none
+	recErr        error                // first error writing a recorded update
+	stepChan      chan struct{}        // advances a stepped replay; holds one pending step
+	alertLineID   int                  // identifier of rolling line field that receives alert entries
+	alertLineOK   bool                 // alertLineID has been assigned
 }
 
-var scr screenType
+var (
+	dotStr   = strings.Repeat(".", cnMaxWidth) // separation for key/value fields
+	blankStr = strings.Repeat(" ", cnMaxWidth) // overflow blank string to clear characters of previous string
+	diamonds [cnMaxWidth]rune                  // overflow indictor
+	lines    [cnMaxWidth]rune                  // line made up of horizontal line runes
+	blocks   [cnMaxWidth]rune                  // filled portion of bar
+	boards   [cnMaxWidth]rune                  // unfilled portion of bar
+)
+
+// std is the dashboard used by the package-level functions. Its screen is
+// created when Run() is called.
+var std = New(nil)
 
 type itemType int
 
@@ -42,104 +100,247 @@ const (
 	itemHeaderLine
 	itemLine
 	itemWalk
+	itemProgress
+	itemGauge
+	itemChart
+	itemTable
+	itemStats
 )
 
 const (
 	updateScreen int = iota // internal flag must be set
-	updateStop
+	updateKey
+	updateLayout
+	updateTheme
+	updateModal
+	updateUnregister
+	updateVisible
+	updateKeyStr
+	updateSnapshot
+	updateAlert
 )
 
 type updateType struct {
-	internal bool   // true if id is defined internally
-	id       int    // application defined field identifier
-	str      string // string value
-	ok       bool   // flag for walk line
+	internal bool            // true if id is defined internally
+	id       int             // application defined field identifier
+	str      string          // string value
+	ok       bool            // flag for walk line
+	val      float64         // numeric value for progress, gauge and chart fields
+	ev       *tcell.EventKey // key event for updateKey
+	lay      *LayoutType     // layout for updateLayout
+	thm      *ThemeType      // theme for updateTheme
+	modal    *modalType      // prompt or menu for updateModal
+	snap     chan *snapType  // receives screen contents for updateSnapshot
+	alert    *alertType      // rule for updateAlert, nil to remove all rules
+	target   int             // field identifier for updateUnregister, updateVisible, updateKeyStr and updateAlert
+	style    int             // value style, StyleValue, StyleWarn, StyleError or StyleDim
+	level    int             // rolling line severity, LevelNone through LevelError
+	src      string          // rolling line source
+	vals     []interface{}   // table row values
+	cells    []string        // formatted table row cells, used instead of vals when replaying
+	del      bool            // remove table row
 }
 
 type fieldType struct {
-	id         int               // user-defined field identifier
-	item       itemType          // type of field key-value, header, etc
-	str        string            // static string, may include tabs for left, center, right alignment
-	x, y       int               // starting position
-	wd         int               // width of field, 0 for entire line
-	strList    []strings.Builder // series of strings for rolling logs
-	prefixLen  int               // number of characters in strList[x] timestamp prefix
-	pos        int               // walk position; for log series, next strList position to fill
-	count      int               // number of builders assigned in strList
-	timeFmtStr string            // timestamp format, empty for no timestamp
+	id         int             // user-defined field identifier
+	item       itemType        // type of field key-value, header, etc
+	str        string          // static string, may include tabs for left, center, right alignment
+	x, y       int             // starting position
+	wd         int             // width of field, 0 for entire line
+	recList    []lineRecType   // series of entries for rolling logs
+	minLevel   int             // minimum severity level of rolling line entries shown
+	scroll     int             // number of newest log entries scrolled out of view below the field; for tables, first row shown
+	ht         int             // number of chart or rolling line rows
+	pos        int             // walk position; for log series and charts, next recList or valList position to fill
+	count      int             // number of entries assigned in recList or values in valList; for walk, nonzero after first update
+	ok         bool            // walk block shown as successful
+	timeFmtStr string          // timestamp format, empty for no timestamp
+	valStr     string          // most recent key/value value or progress label
+	style      int             // style of most recent key/value value
+	changeTm   time.Time       // time the key/value value last changed
+	alertList  []*alertType    // key/value alert rules
+	alerted    bool            // an alert rule holds
+	alertStyle int             // style of the first alert rule that holds
+	flash      int             // remaining alert periods of flashing
+	changed    bool            // updated since the last headless snapshot
+	fresh      int             // number of rolling line entries added since the last headless snapshot
+	val        float64         // most recent progress fraction or gauge value
+	startVal   float64         // progress fraction at startTm
+	startTm    time.Time       // time of first progress update, used to estimate completion
+	min, max   float64         // gauge range
+	thresholds []ThresholdType // gauge colour thresholds in ascending order
+	valList    []float64       // series of chart samples
+	cols       []ColumnType    // table columns
+	rowList    []tableRowType  // table rows in the order they were added
+	sortCol    int             // table sort column, -1 for none
+	sortDesc   bool            // table sorted in descending order
+	dirty      bool            // updated since the screen was last refreshed
+	hidden     bool            // not drawn and skipped by layouts
+	squeezed   bool            // placed by the layout in a panel with no room, so not drawn
 }
 
 type fieldPtrType *fieldType
 
 const (
-	cnWalkWidth = 3
-	cnMaxWidth  = 256
+	cnWalkWidth   = 3
+	cnMaxWidth    = 256
+	cnUpdateCount = 256 // capacity of update channel
+	cnFrameRate   = 30  // default maximum screen refreshes per second
 )
 
 func init() {
-	var err error
 	for j := 0; j < cnMaxWidth; j++ {
-		scr.diamonds[j] = tcell.RuneDiamond
-		scr.lines[j] = tcell.RuneHLine
-	}
-	scr.dotStr = strings.Repeat(".", cnMaxWidth)
-	scr.blankStr = strings.Repeat(" ", cnMaxWidth)
-	scr.buf = &strings.Builder{}
-	scr.buf.Grow(4 * cnMaxWidth)
-	scr.fieldMap = make(map[int]fieldPtrType)
-	scr.updateChan = make(chan updateType, 256)
-	scr.screen, err = tcell.NewScreen()
-	scr.updateable = true
-	if err == nil {
-		// encoding.Register() // Asian encodings, adds several megabytes to application size
+		diamonds[j] = tcell.RuneDiamond
+		lines[j] = tcell.RuneHLine
+		blocks[j] = tcell.RuneBlock
+		boards[j] = tcell.RuneBoard
 	}
-	if err != nil {
-		panic(err)
+}
+
+// send queues the field update specified by up without blocking. If the
+// update channel is full, up is discarded and counted.
+func (dsh *Dashboard) send(up updateType) {
+	select {
+	case dsh.updateChan <- up:
+	default:
+		atomic.AddInt64(&dsh.dropped, 1)
 	}
 }
 
-func listen(updateChan chan<- updateType, pollEvent func() tcell.Event, quitRunes ...rune) {
-	var rn rune
-	quitMap := make(map[rune]bool)
-	for _, rn = range quitRunes {
-		quitMap[rn] = true
+// control queues the internal update specified by up for the 'dashboard show'
+// goroutine without blocking. Unlike field updates, control requests are
+// never discarded.
+func (dsh *Dashboard) control(up updateType) {
+	up.internal = true
+	dsh.ctrlMtx.Lock()
+	dsh.ctrlList = append(dsh.ctrlList, up)
+	dsh.ctrlMtx.Unlock()
+	select {
+	case dsh.ctrlChan <- struct{}{}:
+	default:
+		// A signal is already pending
 	}
-	loop := true
-	for loop {
-		ev := pollEvent()
-		loop = ev != nil
-		if loop {
-			rn = 0
-			// log.Printf("event")
-			switch ev := ev.(type) {
-			case *tcell.EventKey:
-				switch ev.Key() {
-				case tcell.KeyEscape:
-					rn = 27
-				case tcell.KeyCtrlL:
-					updateChan <- updateType{internal: true, id: updateScreen}
-				case tcell.KeyRune:
-					rn = ev.Rune()
-				}
-			case *tcell.EventResize:
-				updateChan <- updateType{internal: true, id: updateScreen}
-			}
-			if rn > 0 {
-				_, ok := quitMap[rn]
-				if ok {
-					loop = false
-				}
+}
+
+// ctrlTake removes and returns the queued control requests.
+func (dsh *Dashboard) ctrlTake() (list []updateType) {
+	dsh.ctrlMtx.Lock()
+	list = dsh.ctrlList
+	dsh.ctrlList = nil
+	dsh.ctrlMtx.Unlock()
+	return
+}
+
+// New returns an initialized dashboard that renders to screen. If screen is
+// nil, a terminal screen is created when Run() is called. Fields may be
+// registered and updated as soon as this function returns. Multiple
+// dashboards, each with its own screen, may be used in the same process.
+func New(screen tcell.Screen) (dsh *Dashboard) {
+	dsh = &Dashboard{screen: screen, updateable: true}
+	dsh.buf = &strings.Builder{}
+	dsh.buf.Grow(4 * cnMaxWidth)
+	dsh.fieldMap = make(map[int]fieldPtrType)
+	dsh.updateChan = make(chan updateType, cnUpdateCount)
+	dsh.ctrlChan = make(chan struct{}, 1)
+	dsh.frame = time.Second / cnFrameRate
+	dsh.stepChan = make(chan struct{}, 1)
+	return
+}
+
+// listen forwards screen events to evChan until pollEvent returns nil, which
+// happens when the screen is finalized, or ctx is done. Ctrl-L and resize
+// events request a full redraw; other key events are handled by the
+// 'dashboard show' goroutine. Screen events use their own channel so that
+// they are neither delayed by a backlog of field updates nor left over for a
+// later run.
+func listen(ctx context.Context, evChan chan<- updateType, pollEvent func() tcell.Event) {
+	for ev := pollEvent(); ev != nil; ev = pollEvent() {
+		var up updateType
+		// log.Printf("event")
+		switch ev := ev.(type) {
+		case *tcell.EventKey:
+			if ev.Key() == tcell.KeyCtrlL {
+				up = updateType{internal: true, id: updateScreen}
+			} else {
+				up = updateType{internal: true, id: updateKey, ev: ev}
 			}
+		case *tcell.EventResize:
+			up = updateType{internal: true, id: updateScreen}
+		default:
+			continue
+		}
+		select {
+		case evChan <- up:
+		case <-ctx.Done():
+			return
 		}
 	}
-	updateChan <- updateType{internal: true, id: updateStop}
 }
 
-func put(style tcell.Style, x, y, scrWd int, strs ...string) (newX int) {
+// keyHandle responds to the key event specified by ev. An open prompt or menu
+// receives all keys, and any key dismisses the help overlay. Otherwise,
+// application key bindings are handled first (except while a search pattern is
+// being entered), then navigation keys and the help key; quit is returned true
+// if ev is one of the quit runes.
+func (dsh *Dashboard) keyHandle(ev *tcell.EventKey) (quit bool) {
+	var rn rune
+	if dsh.modalKey(ev) {
+		return
+	}
+	if dsh.helpShow {
+		dsh.helpShow = false
+		dsh.render()
+		dsh.screen.Show()
+		return
+	}
+	if !dsh.searchEdit && dsh.keyCall(ev) {
+		return
+	}
+	if dsh.lineKey(ev) {
+		return
+	}
+	if ev.Key() == tcell.KeyRune && ev.Rune() == '?' {
+		dsh.helpShow = true
+		dsh.render()
+		dsh.screen.Show()
+		return
+	}
+	switch ev.Key() {
+	case tcell.KeyEscape:
+		rn = 27
+	case tcell.KeyRune:
+		rn = ev.Rune()
+	}
+	if rn > 0 {
+		quit = dsh.quitMap[rn]
+	}
+	return
+}
+
+// put writes strs starting at column x of row y and returns the column that
+// follows them. Each character advances by its display width, and combining
+// characters are attached to the character that precedes them. Nothing is
+// written at or beyond column scrWd; a wide character that would straddle it
+// is replaced with a blank.
+func (dsh *Dashboard) put(style tcell.Style, x, y, scrWd int, strs ...string) (newX int) {
+	var mainRn rune
+	var comb []rune
+	mainX := -1
 	for _, str := range strs {
 		for _, r := range str {
-			if x < scrWd {
-				scr.screen.SetContent(x, y, r, nil, style)
+			wd := runewidth.RuneWidth(r)
+			if wd == 0 {
+				if mainX >= 0 {
+					comb = append(comb, r)
+					dsh.screen.SetContent(mainX, y, mainRn, comb, style)
+				}
+			} else if x+wd <= scrWd {
+				mainRn, mainX, comb = r, x, nil
+				dsh.screen.SetContent(x, y, r, nil, style)
+				x += wd
+			} else if x < scrWd {
+				mainX = -1
+				dsh.screen.SetContent(x, y, ' ', nil, style)
 				x++
 			}
 		}
@@ -148,26 +349,38 @@ func put(style tcell.Style, x, y, scrWd int, strs ...string) (newX int) {
 	return
 }
 
-func keyval(styleKey, styleVal tcell.Style, x, y, wd, scrWd int,
+func (dsh *Dashboard) keyval(styleKey, styleVal tcell.Style, x, y, wd, scrWd int,
 	keyStr string, valStr string) {
-	keyLen := len(keyStr)
-	valLen := len(valStr)
+	keyLen := util.StrWidth(keyStr)
+	valLen := util.StrWidth(valStr)
 	if wd > cnMaxWidth {
 		wd = cnMaxWidth
+	} else if wd < 0 {
+		wd = 0
 	}
 	if keyLen+valLen+4 <= wd {
-		x = put(styleKey, x, y, scrWd, keyStr, " ", scr.dotStr[:wd-2-keyLen-valLen], " ")
-		put(styleVal, x, y, scrWd, valStr)
+		x = dsh.put(styleKey, x, y, scrWd, keyStr, " ", dotStr[:wd-2-keyLen-valLen], " ")
+		dsh.put(styleVal, x, y, scrWd, valStr)
 	} else {
-		put(styleKey, x, y, scrWd, string(scr.diamonds[:wd]))
+		dsh.put(styleKey, x, y, scrWd, string(diamonds[:wd]))
+	}
+}
+
+// fieldWidth returns the width of the field specified by fld. A zero or
+// negative field width is taken relative to the right edge of the screen.
+func fieldWidth(fld fieldPtrType, scrWd int) (wd int) {
+	wd = fld.wd
+	if wd <= 0 {
+		wd = scrWd + wd - fld.x
 	}
+	return
 }
 
-func walk(screen tcell.Screen, plainStyle, blockStyle tcell.Style, y, pos, wd int) {
+func (dsh *Dashboard) walk(plainStyle, blockStyle tcell.Style, left, y, pos, wd, scrWd int) {
 	offPos := pos + cnWalkWidth
 	var outerRune, innerRune rune
 	var style tcell.Style
-	for x := 0; x < wd; x++ {
+	for x := 0; x < wd && left+x < scrWd; x++ {
 		if x >= pos && x < offPos {
 			outerRune = tcell.RuneBlock
 			innerRune = tcell.RuneBlock
@@ -177,13 +390,29 @@ func walk(screen tcell.Screen, plainStyle, blockStyle tcell.Style, y, pos, wd in
 			innerRune = tcell.RuneHLine
 			style = plainStyle
 		}
-		screen.SetContent(x, y, outerRune, nil, style)
-		screen.SetContent(x, y+1, innerRune, nil, style)
-		screen.SetContent(x, y+2, outerRune, nil, style)
+		dsh.screen.SetContent(left+x, y, outerRune, nil, style)
+		dsh.screen.SetContent(left+x, y+1, innerRune, nil, style)
+		dsh.screen.SetContent(left+x, y+2, outerRune, nil, style)
+	}
+}
+
+// walkPut draws the walk field specified by fld with its block at the current
+// position. The block is shown with okSt if the most recent update was
+// successful, otherwise failSt. The position is wrapped if the field has
+// become too narrow to hold it.
+func (dsh *Dashboard) walkPut(plainSt, okSt, failSt tcell.Style, scrWd int, fld fieldPtrType) {
+	st := failSt
+	if fld.ok {
+		st = okSt
+	}
+	wd := fieldWidth(fld, scrWd)
+	if fld.pos+cnWalkWidth > wd {
+		fld.pos = 0
 	}
+	dsh.walk(plainSt, st, fld.x, fld.y, fld.pos, wd, scrWd)
 }
 
-func headerPut(boldSt, keySt, valSt tcell.Style, scrWd int, fld fieldPtrType) {
+func (dsh *Dashboard) headerPut(boldSt, keySt, valSt tcell.Style, scrWd int, fld fieldPtrType) {
 	list := strings.Split(fld.str, "\\t")
 	var gapA, gapB int
 	for j := len(list); j < 3; j++ {
@@ -192,12 +421,12 @@ func headerPut(boldSt, keySt, valSt tcell.Style, scrWd int, fld fieldPtrType) {
 	// for j, str := range list {
 	// 	log.Printf("Field %d, string %d: [%s]", fld.id, j, str)
 	// }
-	totalLen := len(list[0]) + len(list[1]) + len(list[2])
-	wd := fld.wd
-	if wd <= 0 {
-		wd = scrWd + wd - fld.x
-	}
+	totalLen := util.StrWidth(list[0]) + util.StrWidth(list[1]) + util.StrWidth(list[2])
+	wd := fieldWidth(fld, scrWd)
 	gap := wd - totalLen
+	if gap > cnMaxWidth {
+		gap = cnMaxWidth
+	}
 	if gap < 2 { // tail will be truncated by put()
 		gapA = 1
 		gapB = 1
@@ -206,119 +435,244 @@ func headerPut(boldSt, keySt, valSt tcell.Style, scrWd int, fld fieldPtrType) {
 		gapB = gap - gapA
 	}
 	if fld.item == itemHeader {
-		scr.buf.Reset()
-		fmt.Fprintf(scr.buf, "%s%s%s%s%s", list[0], scr.blankStr[:gapA], list[1], scr.blankStr[:gapB], list[2])
-		str := scr.buf.String()
-		put(boldSt, fld.x, fld.y, scrWd, str)
+		dsh.buf.Reset()
+		fmt.Fprintf(dsh.buf, "%s%s%s%s%s", list[0], blankStr[:gapA], list[1], blankStr[:gapB], list[2])
+		str := dsh.buf.String()
+		dsh.put(boldSt, fld.x, fld.y, scrWd, str)
 	} else {
-		x := put(valSt, fld.x, fld.y, scrWd, list[0])
-		x = put(keySt, x, fld.y, scrWd, string(scr.lines[:gapA]))
-		x = put(valSt, x, fld.y, scrWd, list[1])
-		x = put(keySt, x, fld.y, scrWd, string(scr.lines[:gapB]))
-		put(valSt, x, fld.y, scrWd, list[2])
+		x := dsh.put(valSt, fld.x, fld.y, scrWd, list[0])
+		x = dsh.put(keySt, x, fld.y, scrWd, string(lines[:gapA]))
+		x = dsh.put(valSt, x, fld.y, scrWd, list[1])
+		x = dsh.put(keySt, x, fld.y, scrWd, string(lines[:gapB]))
+		dsh.put(valSt, x, fld.y, scrWd, list[2])
 	}
 }
 
-func headerRender(boldSt, keySt, valSt tcell.Style) {
-	var list []fieldPtrType
-	scrWd, _ := scr.screen.Size()
-	scr.fieldMtx.Lock()
-	for _, fieldPtr := range scr.fieldMap {
-		if fieldPtr.item == itemHeader || fieldPtr.item == itemHeaderLine {
-			list = append(list, fieldPtr)
+// fieldList returns the registered fields in order of identifier.
+func (dsh *Dashboard) fieldList() (list []fieldPtrType) {
+	dsh.fieldMtx.Lock()
+	for _, fieldPtr := range dsh.fieldMap {
+		list = append(list, fieldPtr)
+	}
+	dsh.fieldMtx.Unlock()
+	sort.Slice(list, func(a, b int) bool {
+		return list[a].id < list[b].id
+	})
+	return
+}
+
+// fieldUpdate records the value carried by up in the field specified by fld.
+// The count field is nonzero once a field other than a header has received
+// its first update.
+func fieldUpdate(fld fieldPtrType, up updateType) {
+	switch fld.item {
+	case itemKeyVal:
+		if fld.count == 0 || up.str != fld.valStr {
+			fld.changeTm = time.Now()
 		}
+		fld.valStr = up.str
+		fld.style = up.style
+		fld.count = 1
+	case itemLine:
+		// log.Printf("line [%s]", up.str)
+		lineAdd(fld, lineRecType{tm: time.Now(), level: up.level, srcStr: up.src, msgStr: up.str})
+	case itemWalk:
+		if fld.count > 0 {
+			fld.pos += cnWalkWidth
+		} else {
+			fld.count = 1
+		}
+		fld.ok = up.ok
+	case itemProgress:
+		progressUpdate(fld, up.val)
+		fld.valStr = up.str
+		fld.count = 1
+	case itemGauge:
+		fld.val = up.val
+		fld.count = 1
+	case itemChart:
+		chartAdd(fld, up.val)
+	case itemTable:
+		tableUpdate(fld, up)
 	}
-	scr.fieldMtx.Unlock()
-	for _, fieldPtr := range list {
-		headerPut(boldSt, keySt, valSt, scrWd, fieldPtr)
+}
+
+// fieldPut draws the field specified by fld using its most recent value.
+// Fields other than headers are not drawn until they have been updated, and
+// hidden fields, or fields in layout panels with no room, are not drawn at
+// all.
+func (dsh *Dashboard) fieldPut(scrWd int, fld fieldPtrType) {
+	thm := &dsh.theme
+	if fld.hidden || fld.squeezed {
+		return
 	}
-	if len(list) > 0 {
-		scr.screen.Show()
+	switch fld.item {
+	case itemHeader, itemHeaderLine:
+		dsh.headerPut(thm.Banner, thm.Key, thm.Value, scrWd, fld)
+	case itemLine:
+		dsh.linePut(scrWd, fld)
+	case itemChart:
+		dsh.chartPut(thm.Key, thm.Value, scrWd, fld)
+	case itemTable:
+		dsh.tablePut(scrWd, fld)
+	}
+	if fld.count > 0 {
+		switch fld.item {
+		case itemKeyVal, itemStats:
+			// log.Printf("scr.keyval x %d, y %d, wd %d, key %s, val %s", fld.x,
+			// fld.y, fld.wd, fld.str, fld.valStr)
+			st := thm.style(fld.style)
+			if fld.alerted {
+				st = thm.style(fld.alertStyle)
+			}
+			if fld.flash%2 == 1 {
+				st = st.Reverse(true)
+			}
+			dsh.keyval(thm.Key, st, fld.x, fld.y, fieldWidth(fld, scrWd), scrWd, fld.str, fld.valStr)
+		case itemWalk:
+			dsh.walkPut(thm.Key, thm.OK, thm.Error, scrWd, fld)
+		case itemProgress:
+			dsh.progressPut(thm.Key, thm.Value, thm.OK, scrWd, fld)
+		case itemGauge:
+			dsh.gaugePut(thm.Key, thm.Value, scrWd, fld)
+		}
 	}
 }
 
-func run() {
+// render clears the screen and draws all registered fields followed by any
+// overlay. If a layout has been assigned, field positions are recalculated
+// for the current screen size first.
+func (dsh *Dashboard) render() {
+	dsh.screen.Clear()
+	scrWd, scrHt := dsh.screen.Size()
+	if dsh.layout != nil {
+		dsh.layoutApply(scrWd, scrHt)
+	}
+	for _, fieldPtr := range dsh.fieldList() {
+		dsh.fieldPut(scrWd, fieldPtr)
+	}
+	dsh.overlayPut()
+}
+
+// overlayPut draws the help overlay if it is shown, and then the active
+// prompt or menu. It is called after fields are drawn so that overlays remain
+// on top.
+func (dsh *Dashboard) overlayPut() {
+	scrWd, scrHt := dsh.screen.Size()
+	if dsh.helpShow {
+		dsh.helpPut(scrWd, scrHt)
+	}
+	dsh.modalPut(scrWd, scrHt)
+}
+
+// run draws the dashboard and processes field updates from updateChan and
+// screen events from evChan until ctx is done or a quit rune is pressed.
+func (dsh *Dashboard) run(ctx context.Context, evChan <-chan updateType) {
 	// var logList [cnLogCount]string
 	// var logPos, logCount int
 	// const left = 1
-	plain := tcell.StyleDefault.Foreground(tcell.ColorYellow)
-	white := tcell.StyleDefault.Foreground(tcell.ColorWhite)
-	banner := tcell.StyleDefault.Foreground(tcell.ColorBlack).Background(tcell.ColorYellow)
-	// red := tcell.StyleDefault.Foreground(tcell.ColorRed)
-	// green := tcell.StyleDefault.Foreground(tcell.ColorGreen)
 	// wd, ht := scr.screen.Size()
-	// headerRender(banner)
+	var frameChan <-chan time.Time
+	var dirtyList []fieldPtrType
+	var frameTm time.Time
+	markDirty := func(fieldPtr fieldPtrType) {
+		if !fieldPtr.dirty {
+			fieldPtr.dirty = true
+			dirtyList = append(dirtyList, fieldPtr)
+		}
+		if frameChan == nil {
+			// A negative wait fires immediately
+			frameChan = time.After(dsh.frame - time.Since(frameTm))
+		}
+	}
+	alertCheck := func(fieldPtr fieldPtrType, tm time.Time) {
+		changed, lineFld := dsh.alertCheck(fieldPtr, tm)
+		if changed {
+			markDirty(fieldPtr)
+		}
+		if lineFld != nil {
+			markDirty(lineFld)
+		}
+	}
+	alertTick := time.NewTicker(cnAlertPeriod)
+	defer alertTick.Stop()
+	for _, fieldPtr := range dsh.fieldList() {
+		fieldPtr.dirty = false
+	}
+	dsh.statTm = time.Now()
+	dsh.render()
+	dsh.screen.Show()
 	loop := true
-	// walkPos := 0
 	// syncCount := 0
 	for loop {
-		up := <-scr.updateChan
+		var up updateType
+		select {
+		case up = <-dsh.updateChan:
+		case up = <-evChan:
+		case <-dsh.ctrlChan:
+			for _, up := range dsh.ctrlTake() {
+				dsh.controlApply(up)
+			}
+			continue
+		case <-ctx.Done():
+			loop = false
+			continue
+		case frameTm = <-frameChan:
+			// Fields updated since the last frame are drawn once each
+			scrWd, _ := dsh.screen.Size()
+			frameChan = nil
+			for _, fieldPtr := range dirtyList {
+				fieldPtr.dirty = false
+				dsh.fieldPut(scrWd, fieldPtr)
+			}
+			dirtyList = dirtyList[:0]
+			for _, fieldPtr := range dsh.statsUpdate(frameTm) {
+				dsh.fieldPut(scrWd, fieldPtr)
+			}
+			dsh.overlayPut()
+			dsh.screen.Show()
+			continue
+		case tm := <-alertTick.C:
+			// Stale values and flashes depend on time rather than updates
+			for _, fieldPtr := range dsh.fieldList() {
+				if fieldPtr.flash > 0 {
+					fieldPtr.flash--
+					markDirty(fieldPtr)
+				}
+				if len(fieldPtr.alertList) > 0 {
+					alertCheck(fieldPtr, tm)
+				}
+			}
+			continue
+		}
 		if up.internal {
 			// log.Printf("internal")
 			switch up.id {
 			case updateScreen:
-				headerRender(banner, plain, white)
-				scr.screen.Sync()
-			case updateStop:
-				loop = false
+				dsh.render()
+				dsh.screen.Sync()
+			case updateKey:
+				if dsh.keyHandle(up.ev) {
+					loop = false
+				}
 			}
 		} else {
 			// log.Printf("external")
-			// var st tcell.Style
 			var fieldPtr fieldPtrType
 			var ok bool
-			scr.fieldMtx.Lock()
-			fieldPtr, ok = scr.fieldMap[up.id]
-			scr.fieldMtx.Unlock()
+			dsh.fieldMtx.Lock()
+			fieldPtr, ok = dsh.fieldMap[up.id]
+			dsh.fieldMtx.Unlock()
 			if ok {
-				scrWd, _ := scr.screen.Size()
 				// log.Printf("good field %d", up.id)
-				switch fieldPtr.item {
-				case itemKeyVal:
-					// log.Printf("scr.keyval x %d, y %d, wd %d, key %s, val %s", fieldPtr.x,
-					// fieldPtr.y, fieldPtr.wd, fieldPtr.str, up.str)
-					keyval(plain, white, fieldPtr.x, fieldPtr.y, fieldPtr.wd, scrWd, fieldPtr.str, up.str)
-				case itemLine:
-					// log.Printf("line [%s]", up.str)
-					count := fieldPtr.count
-					size := len(fieldPtr.strList)
-					if count < size {
-						count++
-						fieldPtr.count = count
-					}
-					pos := fieldPtr.pos
-					fieldPtr.strList[pos].Reset()
-					if fieldPtr.timeFmtStr != "" {
-						// TODO rather than concatenate, support two string buffers for
-						// different attributes
-						fieldPtr.strList[pos].WriteString(time.Now().Format(fieldPtr.timeFmtStr))
-						fieldPtr.prefixLen = fieldPtr.strList[pos].Len()
-					}
-					fieldPtr.strList[pos].WriteString(up.str)
-					pos++
-					if pos >= size {
-						pos = 0
-					}
-					fieldPtr.pos = pos
-					for j := 0; j < count; j++ {
-						k := pos + j
-						if k >= count {
-							k -= count
-						}
-						top := fieldPtr.y
-						str := fieldPtr.strList[k].String()
-						left := put(plain, fieldPtr.x, top+j, scrWd, str[:fieldPtr.prefixLen])
-						str = str[fieldPtr.prefixLen:]
-						length := len(str)
-						if length+left <= scrWd {
-							put(white, left, top+j, scrWd, str, scr.blankStr[:scrWd-length-left])
-						} else {
-							put(white, left, top+j, scrWd, str[:scrWd-left-2], "..")
-						}
-					}
-				case itemWalk:
+				dsh.received++
+				fieldUpdate(fieldPtr, up)
+				dsh.record(fieldPtr, up)
+				dsh.publish(fieldPtr)
+				markDirty(fieldPtr)
+				if len(fieldPtr.alertList) > 0 {
+					alertCheck(fieldPtr, time.Now())
 				}
-				scr.screen.Show()
 			}
 		}
 
@@ -349,17 +703,6 @@ func run() {
 		// 			put(scr.screen, white, left, 11+j, str[:wd-left-2], "..")
 		// 		}
 		// 	}
-		// case updateWalk:
-		// 	if up.ok {
-		// 		st = green
-		// 	} else {
-		// 		st = red
-		// 	}
-		// 	walk(scr.screen, plain, st, 5, walkPos, wd)
-		// 	walkPos += cnWalkWidth
-		// 	if walkPos+cnWalkWidth >= wd {
-		// 		walkPos = 0
-		// 	}
 		// 		case updateScreen:
 		// 			wd, ht = scr.screen.Size()
 		// 			syncCount++
@@ -369,51 +712,134 @@ func run() {
 		// 			scr.screen.Sync()
 		// }
 	}
+	dsh.modalCancel()
 	// close(scr.quitChan)
 }
 
-func fieldRegister(id int, fldPtr fieldPtrType) {
-	scr.fieldMtx.Lock()
-	scr.fieldMap[id] = fldPtr
-	scr.fieldMtx.Unlock()
+// controlApply carries out the control request specified by up on behalf of
+// run().
+func (dsh *Dashboard) controlApply(up updateType) {
+	switch up.id {
+	case updateTheme:
+		dsh.theme = *up.thm
+		dsh.themeSet = true
+	case updateLayout:
+		dsh.layout = up.lay
+	case updateModal:
+		dsh.modalList = append(dsh.modalList, up.modal)
+	case updateSnapshot:
+		up.snap <- dsh.snapCapture()
+		return
+	case updateUnregister, updateVisible, updateKeyStr, updateAlert:
+		// Redrawing everything clears the field's previous area
+		dsh.fieldChange(up)
+	}
+	dsh.render()
+	dsh.screen.Show()
 }
 
-// RegisterLine registers a dashboard rolling line field with the identifier
-// specified by id. Its coordinates are specified by x and y, and the total
-// number of rows used is specified by lineCount. If timeFmt is empty, no
-// timestamp prefix will be displayed. Otherwise, it will be used to format a
-// leading timestamp.
-func RegisterLine(id, x, y, lineCount int, timeFmtStr string) {
-	var fld fieldType
+func (dsh *Dashboard) fieldRegister(id int, fldPtr fieldPtrType) {
+	dsh.fieldMtx.Lock()
+	dsh.fieldMap[id] = fldPtr
+	dsh.fieldMtx.Unlock()
+}
 
-	fld.strList = make([]strings.Builder, lineCount)
-	for j := 0; j < lineCount; j++ {
-		fld.strList[j].Grow(cnMaxWidth)
+// fieldChange removes, hides, shows or relabels the field specified by
+// up.target, or changes its alert rules, according to up.id. A field that is
+// removed or hidden loses the keyboard focus. changed is false if the field is
+// not registered.
+func (dsh *Dashboard) fieldChange(up updateType) (changed bool) {
+	var fld fieldPtrType
+	dsh.fieldMtx.Lock()
+	fld, changed = dsh.fieldMap[up.target]
+	if changed {
+		switch up.id {
+		case updateUnregister:
+			// A pending redraw of the field is suppressed by hiding it
+			delete(dsh.fieldMap, up.target)
+			fld.hidden = true
+		case updateVisible:
+			fld.hidden = !up.ok
+		case updateKeyStr:
+			fld.str = up.str
+		case updateAlert:
+			if up.alert == nil {
+				fld.alertList = nil
+				fld.alerted = false
+				fld.flash = 0
+			} else {
+				fld.alertList = append(fld.alertList, up.alert)
+			}
+		}
+	}
+	dsh.fieldMtx.Unlock()
+	if changed && fld.hidden && dsh.focusOK && dsh.focusID == up.target {
+		dsh.focusOK = false
+		dsh.searchEdit = false
+		dsh.searchStr = ""
 	}
-	fld.item = itemLine
-	fld.id = id
-	fld.x = x
-	fld.y = y
-	fld.timeFmtStr = timeFmtStr
-	fieldRegister(id, &fld)
+	return
+}
+
+// Unregister removes the field specified by id from the dashboard. The area
+// it occupied is cleared. The identifier may be registered again later.
+func (dsh *Dashboard) Unregister(id int) {
+	dsh.control(updateType{id: updateUnregister, target: id})
 }
 
-// UpdateLine updates the rolling line field specified by id with the str.
-func UpdateLine(id int, str string) {
-	scr.updateChan <- updateType{id: id, str: str}
+// Hide stops drawing the field specified by id and clears the area it
+// occupied. The field continues to receive updates, and its current value is
+// shown when Show() is called. Hidden fields are skipped by layouts, so the
+// fields that follow them in a panel move up.
+func (dsh *Dashboard) Hide(id int) {
+	dsh.control(updateType{id: updateVisible, target: id, ok: false})
+}
+
+// Show resumes drawing the field specified by id after a call to Hide().
+func (dsh *Dashboard) Show(id int) {
+	dsh.control(updateType{id: updateVisible, target: id, ok: true})
+}
+
+// SetKey replaces the static key or text of the field specified by id, for
+// example the key of a key/value pair or the text of a header, with str.
+func (dsh *Dashboard) SetKey(id int, str string) {
+	dsh.control(updateType{id: updateKeyStr, target: id, str: str})
+}
+
+// RegisterWalk registers a dashboard activity indicator with the identifier
+// specified by id. Its coordinates are specified by x and y. The field
+// occupies three rows. Its width is specified by wd; a zero value indicates
+// the full width of the screen, and a negative value indicates the position
+// from the right.
+func (dsh *Dashboard) RegisterWalk(id, x, y, wd int) {
+	dsh.fieldRegister(id, &fieldType{id: id, item: itemWalk, x: x, y: y, wd: wd})
+}
+
+// UpdateWalk advances the block of the activity indicator specified by id.
+// The block is shown in green if ok is true, otherwise red. It wraps to the
+// left side of the field when it reaches the right side.
+func (dsh *Dashboard) UpdateWalk(id int, ok bool) {
+	dsh.send(updateType{id: id, ok: ok})
 }
 
 // RegisterKeyVal registers a dashboard key/value pair with the identifier
 // specified by id. Its coordinates are specified by x and y, and the total
 // field's width is specified by wd. The static key is specified by keyStr.
-func RegisterKeyVal(id, x, y, wd int, keyStr string) {
-	fieldRegister(id, &fieldType{id: id, item: itemKeyVal, x: x, y: y, wd: wd, str: keyStr})
+func (dsh *Dashboard) RegisterKeyVal(id, x, y, wd int, keyStr string) {
+	dsh.fieldRegister(id, &fieldType{id: id, item: itemKeyVal, x: x, y: y, wd: wd, str: keyStr})
 }
 
 // UpdateKeyVal updates the key/value pair specified by id with the value
 // specified by str.
-func UpdateKeyVal(id int, str string) {
-	scr.updateChan <- updateType{id: id, str: str}
+func (dsh *Dashboard) UpdateKeyVal(id int, str string) {
+	dsh.send(updateType{id: id, str: str})
+}
+
+// UpdateKeyValStyle updates the key/value pair specified by id with the value
+// specified by str. The value is shown with the theme style specified by
+// style, one of StyleValue, StyleWarn, StyleError or StyleDim.
+func (dsh *Dashboard) UpdateKeyValStyle(id int, str string, style int) {
+	dsh.send(updateType{id: id, str: str, style: style})
 }
 
 // RegisterHeader registers a dashboard static line with the identifier
@@ -421,8 +847,8 @@ func UpdateKeyVal(id int, str string) {
 // width is specified by wd. A zero value for wd indicates the full width of
 // the screen, and a negative value indicates the position from the right. The
 // static key is specified by keyStr.
-func RegisterHeader(id, x, y, wd int, keyStr string) {
-	fieldRegister(id, &fieldType{id: id, item: itemHeader, x: x, y: y, wd: wd, str: keyStr})
+func (dsh *Dashboard) RegisterHeader(id, x, y, wd int, keyStr string) {
+	dsh.fieldRegister(id, &fieldType{id: id, item: itemHeader, x: x, y: y, wd: wd, str: keyStr})
 }
 
 // RegisterHeaderLine registers a dashboard static line with the identifier
@@ -431,51 +857,235 @@ func RegisterHeader(id, x, y, wd int, keyStr string) {
 // the screen, and a negative value indicates the position from the right. The
 // static key is specified by keyStr. A horizontal line is used between string
 // segments.
-func RegisterHeaderLine(id, x, y, wd int, keyStr string) {
-	fieldRegister(id, &fieldType{id: id, item: itemHeaderLine, x: x, y: y, wd: wd, str: keyStr})
+func (dsh *Dashboard) RegisterHeaderLine(id, x, y, wd int, keyStr string) {
+	dsh.fieldRegister(id, &fieldType{id: id, item: itemHeaderLine, x: x, y: y, wd: wd, str: keyStr})
+}
+
+// Run changes the screen to a dashboard. This method does not return until
+// one of the keys included in the list of quitRunes is pressed or Stop() is
+// called. Other keys can be bound to application functions with BindKey();
+// pressing '?' shows them. See SetHeadless() for the behavior of a dashboard
+// without a screen. Up until that time, all application logic should be
+// handled in other goroutines that call one or more of the UpdateXXX()
+// methods to update the dashboard. If the dashboard was created without a
+// screen, a terminal screen is created here; an error is returned if this is
+// not possible, for example when no terminal is present.
+func (dsh *Dashboard) Run(quitRunes ...rune) (err error) {
+	return dsh.RunContext(context.Background(), quitRunes...)
 }
 
-// Run changes the screen to a dashboard. This function does not return until
-// one of the keys included in the list of quitRunes is pressed. Up until that
-// time, all application logic should be handled in other goroutines that call
-// one or more of the UpdateXXX() functions to update the dashboard.
-func Run(quitRunes ...rune) (err error) {
-	err = scr.screen.Init()
-	// log.Printf("start, err == nil: %v", err == nil)
+// RunContext is like Run() but also returns when ctx is done, in which case
+// the context's error is returned. Updateable() reports true while the
+// dashboard runs and false once it has stopped, so that producer goroutines
+// that loop on it exit. Field registrations and values are retained, so the
+// dashboard can be run again afterward; updates sent in the meantime are
+// queued, up to the capacity of the update channel, and shown when it does.
+// A terminal screen created by an earlier run is replaced with a new one. A
+// screen passed to New() or SetScreen() is initialized again, so screens that
+// do not support this, such as tcell's simulation screen, should be replaced
+// with SetScreen() between runs.
+func (dsh *Dashboard) RunContext(ctx context.Context, quitRunes ...rune) (err error) {
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	done := make(chan struct{})
+	defer close(done)
+	dsh.activeMtx.Lock()
+	dsh.cancel = cancel
+	dsh.doneChan = done
+	dsh.activeMtx.Unlock()
+	dsh.updateableSet(true)
+	if dsh.out != nil {
+		dsh.activeSet(true)
+		err = dsh.runHeadless(runCtx)
+		dsh.activeSet(false)
+	} else {
+		if dsh.screen == nil {
+			// encoding.Register() // Asian encodings, adds several megabytes to application size
+			dsh.screen, err = tcell.NewScreen()
+			dsh.ownScreen = err == nil
+		}
+		if err == nil {
+			err = dsh.screen.Init()
+		}
+		// log.Printf("start, err == nil: %v", err == nil)
+		if err == nil {
+			// log.Printf("hide cursor")
+			dsh.screen.HideCursor()
+			// A theme assigned in an earlier run is kept unless the screen
+			// cannot show it
+			if dsh.screen.Colors() < 8 {
+				dsh.theme = MonoTheme()
+			} else if !dsh.themeSet {
+				dsh.theme = DefaultTheme()
+			}
+			dsh.quitMap = make(map[rune]bool)
+			dsh.quitList = quitRunes
+			for _, rn := range quitRunes {
+				dsh.quitMap[rn] = true
+			}
+			evChan := make(chan updateType, 16)
+			go listen(runCtx, evChan, dsh.screen.PollEvent)
+			dsh.activeSet(true)
+			dsh.run(runCtx, evChan)
+			dsh.activeSet(false)
+			log.Printf("stop\n")
+			dsh.screen.Fini()
+			if dsh.ownScreen {
+				dsh.screen = nil
+				dsh.ownScreen = false
+			}
+		}
+	}
+	dsh.updateableSet(false)
+	dsh.activeMtx.Lock()
+	dsh.cancel = nil
+	dsh.activeMtx.Unlock()
+	if err == nil {
+		err = dsh.recErr
+	}
 	if err == nil {
-		// log.Printf("hide cursor")
-		scr.screen.HideCursor()
-		go listen(scr.updateChan, scr.screen.PollEvent, quitRunes...)
-		activeSet(true)
-		run()
-		activeSet(false)
-		log.Printf("stop\n")
-		scr.screen.Fini()
+		err = ctx.Err()
 	}
 	return
 }
 
+// Stop causes a running dashboard to return from Run(). It may be called
+// safely from other goroutines, and has no effect if the dashboard is not
+// running.
+func (dsh *Dashboard) Stop() {
+	dsh.activeMtx.Lock()
+	if dsh.cancel != nil {
+		dsh.cancel()
+	}
+	dsh.activeMtx.Unlock()
+}
+
+// SetScreen assigns the screen used by the next call to Run(). This method
+// must not be called while the dashboard is running.
+func (dsh *Dashboard) SetScreen(screen tcell.Screen) {
+	dsh.screen = screen
+	dsh.ownScreen = false
+}
+
+// updateableSet sets the updateable flag.
+func (dsh *Dashboard) updateableSet(updateable bool) {
+	dsh.updateableMtx.Lock()
+	dsh.updateable = updateable
+	dsh.updateableMtx.Unlock()
+}
+
 // activeSet sets the active flag.
-func activeSet(active bool) {
-	scr.activeMtx.Lock()
-	scr.active = active
-	scr.activeMtx.Unlock()
+func (dsh *Dashboard) activeSet(active bool) {
+	dsh.activeMtx.Lock()
+	dsh.active = active
+	dsh.activeMtx.Unlock()
 }
 
 // Active returns true if the dashboard is currently active. It may be called
 // safely from other goroutines. It is typically used in application loops.
-func Active() (active bool) {
-	scr.activeMtx.Lock()
-	active = scr.active
-	scr.activeMtx.Unlock()
+func (dsh *Dashboard) Active() (active bool) {
+	dsh.activeMtx.Lock()
+	active = dsh.active
+	dsh.activeMtx.Unlock()
 	return
 }
 
-// Updateable returns true if the dashboard is currently updateable. It may be called
-// safely from other goroutines. It is typically used in application loops.
-func Updateable() (updateable bool) {
-	scr.updateableMtx.Lock()
-	updateable = scr.updateable
-	scr.updateableMtx.Unlock()
+// Updateable returns true if the dashboard is currently updateable, that is,
+// from its creation until Run() returns, and again while it is run another
+// time. It may be called safely from other goroutines. It is typically used
+// in application loops.
+func (dsh *Dashboard) Updateable() (updateable bool) {
+	dsh.updateableMtx.Lock()
+	updateable = dsh.updateable
+	dsh.updateableMtx.Unlock()
 	return
 }
+
+// RegisterWalk registers an activity indicator with the default dashboard.
+// See Dashboard.RegisterWalk() for details.
+func RegisterWalk(id, x, y, wd int) {
+	std.RegisterWalk(id, x, y, wd)
+}
+
+// UpdateWalk advances an activity indicator of the default dashboard.
+func UpdateWalk(id int, ok bool) {
+	std.UpdateWalk(id, ok)
+}
+
+// RegisterKeyVal registers a key/value pair with the default dashboard. See
+// Dashboard.RegisterKeyVal() for details.
+func RegisterKeyVal(id, x, y, wd int, keyStr string) {
+	std.RegisterKeyVal(id, x, y, wd, keyStr)
+}
+
+// UpdateKeyVal updates a key/value pair of the default dashboard.
+func UpdateKeyVal(id int, str string) {
+	std.UpdateKeyVal(id, str)
+}
+
+// UpdateKeyValStyle updates a key/value pair of the default dashboard with a
+// styled value. See Dashboard.UpdateKeyValStyle() for details.
+func UpdateKeyValStyle(id int, str string, style int) {
+	std.UpdateKeyValStyle(id, str, style)
+}
+
+// RegisterHeader registers a static line with the default dashboard. See
+// Dashboard.RegisterHeader() for details.
+func RegisterHeader(id, x, y, wd int, keyStr string) {
+	std.RegisterHeader(id, x, y, wd, keyStr)
+}
+
+// RegisterHeaderLine registers a static line with horizontal rules with the
+// default dashboard. See Dashboard.RegisterHeaderLine() for details.
+func RegisterHeaderLine(id, x, y, wd int, keyStr string) {
+	std.RegisterHeaderLine(id, x, y, wd, keyStr)
+}
+
+// Unregister removes a field from the default dashboard. See
+// Dashboard.Unregister() for details.
+func Unregister(id int) {
+	std.Unregister(id)
+}
+
+// Hide stops drawing a field of the default dashboard. See Dashboard.Hide()
+// for details.
+func Hide(id int) {
+	std.Hide(id)
+}
+
+// Show resumes drawing a hidden field of the default dashboard.
+func Show(id int) {
+	std.Show(id)
+}
+
+// SetKey replaces the key or text of a field of the default dashboard.
+func SetKey(id int, str string) {
+	std.SetKey(id, str)
+}
+
+// Run changes the screen to the default dashboard. See Dashboard.Run() for
+// details.
+func Run(quitRunes ...rune) error {
+	return std.Run(quitRunes...)
+}
+
+// RunContext runs the default dashboard until ctx is done. See
+// Dashboard.RunContext() for details.
+func RunContext(ctx context.Context, quitRunes ...rune) error {
+	return std.RunContext(ctx, quitRunes...)
+}
+
+// Stop causes the default dashboard to return from Run().
+func Stop() {
+	std.Stop()
+}
+
+// Active returns true if the default dashboard is currently active.
+func Active() bool {
+	return std.Active()
+}
+
+// Updateable returns true if the default dashboard is currently updateable.
+func Updateable() bool {
+	return std.Updateable()
+}
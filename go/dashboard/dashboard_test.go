@@ -1,14 +1,26 @@
 package dashboard_test
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
 	stdlog "log"
+	"math"
 	"math/rand"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"testing"
 	"time"
 
+	"github.com/gdamore/tcell"
 	"github.com/jung-kurt/etc/go/dashboard"
+	"github.com/jung-kurt/etc/go/longpoll"
 )
 
 const (
@@ -18,6 +30,7 @@ const (
 	cnBannerA
 	cnBannerB
 	cnBannerC
+	cnWalk
 )
 
 func log(str string) {
@@ -74,10 +87,95 @@ func updateLog() {
 	}
 }
 
+func updateWalk() {
+	for dashboard.Updateable() {
+		dashboard.UpdateWalk(cnWalk, rand.Intn(8) > 0)
+		sleep(500)
+	}
+}
+
 func sleep(waitMs int) {
 	time.Sleep(time.Duration(waitMs) * time.Millisecond)
 }
 
+// rowStr returns the runes in row y of the simulation screen specified by sim.
+// Cells are read one at a time with GetContent(), which holds the screen's
+// lock, because the slice returned by GetContents() is modified by the
+// dashboard goroutine when it refreshes the screen.
+func rowStr(sim tcell.SimulationScreen, y int) string {
+	var buf strings.Builder
+	wd, _ := sim.Size()
+	for x := 0; x < wd; x++ {
+		mainc, _, _, _ := sim.GetContent(x, y)
+		if mainc == 0 {
+			mainc = ' '
+		}
+		buf.WriteRune(mainc)
+	}
+	return buf.String()
+}
+
+// cellStyle returns the style of the cell at column x and row y of the
+// simulation screen specified by sim
+func cellStyle(sim tcell.SimulationScreen, x, y int) tcell.Style {
+	_, _, st, _ := sim.GetContent(x, y)
+	return st
+}
+
+// waitRow waits up to two seconds for row y of sim to begin with str
+func waitRow(t *testing.T, sim tcell.SimulationScreen, y int, str string) {
+	var row string
+	for j := 0; j < 200; j++ {
+		row = rowStr(sim, y)
+		if strings.HasPrefix(row, str) {
+			return
+		}
+		sleep(10)
+	}
+	t.Fatalf("expecting row %d to begin with [%s], got [%s]", y, str, row)
+}
+
+// runSim runs the dashboard specified by dsh on a simulation screen. The
+// returned function quits the dashboard and waits for Run() to return.
+func runSim(t *testing.T, dsh *dashboard.Dashboard, sim tcell.SimulationScreen) (quit func()) {
+	errChan := make(chan error)
+	go func() {
+		errChan <- dsh.Run('q')
+	}()
+	for !dsh.Active() {
+		sleep(5)
+	}
+	quit = func() {
+		sim.InjectKey(tcell.KeyRune, 'q', tcell.ModNone)
+		err := <-errChan
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return
+}
+
+// Separate dashboards can be driven independently by simulation screens
+func TestNew(t *testing.T) {
+	simA := tcell.NewSimulationScreen("")
+	simB := tcell.NewSimulationScreen("")
+	dshA := dashboard.New(simA)
+	dshB := dashboard.New(simB)
+	dshA.RegisterKeyVal(cnName, 0, 1, 20, "Name")
+	dshB.RegisterKeyVal(cnName, 0, 2, 20, "Name")
+	quitA := runSim(t, dshA, simA)
+	quitB := runSim(t, dshB, simB)
+	dshA.UpdateKeyVal(cnName, "Tess")
+	dshB.UpdateKeyVal(cnName, "Grant")
+	waitRow(t, simA, 1, "Name .......... Tess")
+	waitRow(t, simB, 2, "Name ......... Grant")
+	quitA()
+	quitB()
+	if dshA.Active() || dshB.Active() {
+		t.Fatalf("expecting inactive dashboards")
+	}
+}
+
 func Example() {
 	var fl *os.File
 	var err error
@@ -92,9 +190,11 @@ func Example() {
 		dashboard.RegisterHeaderLine(cnBannerC, 1, 7, 40, "\\t Dog ")
 		dashboard.RegisterKeyVal(cnName, 1, 8, 40, "Name")
 		dashboard.RegisterHeader(cnBannerB, 0, 9, 0, " This is a banner \\t more work to do \\t Press Q to quit ")
+		dashboard.RegisterWalk(cnWalk, 0, 10, 0)
 		go updateCount()
 		go updateName()
 		go updateLog()
+		go updateWalk()
 		err = dashboard.Run('Q', 'q', 27)
 		stdlog.SetOutput(os.Stdout)
 		fl.Close()
@@ -106,3 +206,784 @@ func Example() {
 	// Output:
 	// Success
 }
+
+// The walk block advances with each update and wraps at the field width
+func TestWalk(t *testing.T) {
+	sim := tcell.NewSimulationScreen("")
+	dsh := dashboard.New(sim)
+	dsh.RegisterWalk(cnWalk, 2, 3, 9)
+	quit := runSim(t, dsh, sim)
+	dsh.UpdateWalk(cnWalk, true)
+	waitRow(t, sim, 4, "  ███──────")
+	dsh.UpdateWalk(cnWalk, false)
+	waitRow(t, sim, 4, "  ───███───")
+	dsh.UpdateWalk(cnWalk, true)
+	waitRow(t, sim, 4, "  ──────███ ")
+	dsh.UpdateWalk(cnWalk, true)
+	waitRow(t, sim, 4, "  ███──────")
+	quit()
+}
+
+// Progress bars and gauges fill in proportion to their values; gauges take
+// the colour of the highest threshold reached
+func TestProgressGauge(t *testing.T) {
+	const (
+		cnProgress = iota
+		cnGauge
+	)
+	sim := tcell.NewSimulationScreen("")
+	dsh := dashboard.New(sim)
+	dsh.RegisterProgress(cnProgress, 0, 0, 20, "Job")
+	dsh.RegisterGauge(cnGauge, 0, 1, 20, "Load", 0, 10,
+		dashboard.ThresholdType{Val: 8, Color: tcell.ColorRed},
+		dashboard.ThresholdType{Val: 5, Color: tcell.ColorYellow})
+	quit := runSim(t, dsh, sim)
+	dsh.UpdateProgress(cnProgress, 0.5, "")
+	waitRow(t, sim, 0, "Job █████░░░░░░  50%")
+	dsh.UpdateGauge(cnGauge, 6)
+	waitRow(t, sim, 1, "Load ██████░░░░ 6.00")
+	fg, _, _ := cellStyle(sim, 5, 1).Decompose()
+	if fg != tcell.ColorYellow {
+		t.Fatalf("expecting yellow gauge, got %v", fg)
+	}
+	dsh.UpdateGauge(cnGauge, 12)
+	waitRow(t, sim, 1, "Load ██████████ 12.0")
+	// Values that are not numbers show an empty bar
+	dsh.UpdateProgress(cnProgress, math.NaN(), "")
+	waitRow(t, sim, 0, "Job ░░░░░░░░░░░   0%")
+	dsh.UpdateGauge(cnGauge, math.NaN())
+	waitRow(t, sim, 1, "Load ░░░░░░░░░░░ NaN")
+	quit()
+}
+
+// Charts show the most recent samples scaled between their minimum and
+// maximum
+func TestChart(t *testing.T) {
+	sim := tcell.NewSimulationScreen("")
+	dsh := dashboard.New(sim)
+	dsh.RegisterChart(cnCount, 1, 0, 4, 2, 6, "Rate")
+	quit := runSim(t, dsh, sim)
+	for j := 0; j < 6; j++ {
+		dsh.UpdateChart(cnCount, float64(j))
+	}
+	waitRow(t, sim, 0, " Rate ")
+	waitRow(t, sim, 1, "   ▃█ ")
+	waitRow(t, sim, 2, " ▁▆██ ")
+	dsh.RegisterChart(cnName, 0, 4, 40, 1, 8, "Load")
+	dsh.UpdateChart(cnName, 2)
+	dsh.UpdateChart(cnName, 4)
+	waitRow(t, sim, 4, "Load min 2.00 max 4.00 last 4.00 ")
+	quit()
+}
+
+// A focused line field scrolls back through its history, stays paused while
+// new entries arrive, and jumps to search matches
+func TestLineScroll(t *testing.T) {
+	sim := tcell.NewSimulationScreen("")
+	dsh := dashboard.New(sim)
+	dsh.RegisterLineHistory(cnLog, 0, 0, 3, 10, "")
+	quit := runSim(t, dsh, sim)
+	for j := 0; j < 6; j++ {
+		dsh.UpdateLine(cnLog, fmt.Sprintf("line %d", j))
+	}
+	waitRow(t, sim, 2, "line 5")
+	key := func(k tcell.Key, rn rune) {
+		sim.InjectKey(k, rn, tcell.ModNone)
+	}
+	key(tcell.KeyTab, 0)
+	key(tcell.KeyUp, 0)
+	waitRow(t, sim, 2, "line 4")
+	dsh.UpdateLine(cnLog, "line 6")
+	key(tcell.KeyUp, 0)
+	waitRow(t, sim, 2, "line 3")
+	key(tcell.KeyEnd, 0)
+	waitRow(t, sim, 2, "line 6")
+	for _, rn := range "/1" {
+		key(tcell.KeyRune, rn)
+	}
+	waitRow(t, sim, 2, "/1 ")
+	key(tcell.KeyEnter, 0)
+	waitRow(t, sim, 1, "line 1")
+	waitRow(t, sim, 0, "line 0")
+	// 'q' is a quit rune, but not while a search pattern is being entered
+	key(tcell.KeyRune, '/')
+	key(tcell.KeyRune, 'q')
+	waitRow(t, sim, 2, "/q ")
+	key(tcell.KeyEscape, 0)
+	quit()
+}
+
+// Layouts place fields in bordered panels and adapt when the screen is
+// resized
+func TestLayout(t *testing.T) {
+	sim := tcell.NewSimulationScreen("")
+	dsh := dashboard.New(sim)
+	dsh.RegisterHeader(cnBannerA, 0, 0, 0, "\\tStatus")
+	dsh.RegisterKeyVal(cnName, 0, 0, 0, "Name")
+	dsh.RegisterKeyVal(cnCount, 0, 0, 0, "Count")
+	lay := dashboard.LayoutType{
+		Children: []dashboard.LayoutType{
+			{Size: 1, Fields: []int{cnBannerA}},
+			{Split: "cols", Children: []dashboard.LayoutType{
+				{Border: true, Title: "A", Fields: []int{cnName}},
+				{Border: true, Weight: 3, Min: 20, Fields: []int{cnCount}},
+			}},
+		},
+	}
+	dsh.SetLayout(lay)
+	quit := runSim(t, dsh, sim)
+	dsh.UpdateKeyVal(cnName, "Tess")
+	dsh.UpdateKeyVal(cnCount, "42")
+	waitRow(t, sim, 1, "┌─ A ──────────────┐┌──")
+	waitRow(t, sim, 2, "│Name ........ Tess││Count ....")
+	sim.SetSize(24, 6)
+	sim.PostEvent(tcell.NewEventResize(24, 6))
+	waitRow(t, sim, 0, "         Status         ")
+	waitRow(t, sim, 2, "│◆◆││Count ......... 42│")
+	waitRow(t, sim, 5, "└──┘└──────────────────┘")
+	// Fields in a panel with no columns are not drawn over their neighbours
+	sim.SetSize(20, 6)
+	sim.PostEvent(tcell.NewEventResize(20, 6))
+	waitRow(t, sim, 1, "┌──────────────────┐")
+	waitRow(t, sim, 2, "│Count ......... 42│")
+	quit()
+	// Fields that do not fit in a panel are not drawn over its border, and
+	// children whose minimum sizes exceed their parent are reduced
+	sim = tcell.NewSimulationScreen("")
+	dsh = dashboard.New(sim)
+	dsh.RegisterKeyVal(cnName, 0, 0, 0, "Name")
+	dsh.RegisterKeyVal(cnCount, 0, 0, 0, "Count")
+	dsh.RegisterKeyVal(cnWalk, 0, 0, 0, "Extra")
+	dsh.SetLayout(dashboard.LayoutType{
+		Children: []dashboard.LayoutType{
+			{Size: 4, Border: true, Fields: []int{cnName, cnCount, cnWalk}},
+			{Size: 2, Children: []dashboard.LayoutType{{Min: 2}, {Min: 2, Border: true}}},
+		},
+	})
+	quit = runSim(t, dsh, sim)
+	dsh.UpdateKeyVal(cnName, "Tess")
+	dsh.UpdateKeyVal(cnCount, "42")
+	dsh.UpdateKeyVal(cnWalk, "7")
+	waitRow(t, sim, 2, "│Count")
+	waitRow(t, sim, 3, "└────────")
+	waitRow(t, sim, 6, strings.Repeat(" ", 80))
+	waitRow(t, sim, 7, strings.Repeat(" ", 80))
+	quit()
+}
+
+// Headless dashboards write field values as JSON snapshots
+func TestHeadless(t *testing.T) {
+	type snapType struct {
+		Time   string
+		Fields []struct {
+			ID    int
+			Key   string
+			Value string
+			Lines []string
+		}
+	}
+	next := func(scanner *bufio.Scanner) (snap snapType) {
+		if !scanner.Scan() {
+			t.Fatalf("expecting snapshot")
+		}
+		err := json.Unmarshal(scanner.Bytes(), &snap)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	rd, wr := io.Pipe()
+	dsh := dashboard.New(nil)
+	dsh.SetHeadless(wr, 10*time.Millisecond, dashboard.FormatJSON)
+	dsh.RegisterKeyVal(cnName, 0, 0, 20, "Name")
+	dsh.RegisterLine(cnLog, 0, 1, 3, "")
+	dsh.RegisterKeyVal(cnCount, 0, 4, 20, "Count")
+	dsh.UpdateKeyVal(cnName, "Prairie")
+	dsh.UpdateLine(cnLog, "alpha")
+	dsh.UpdateLine(cnLog, "beta")
+	go dsh.Run('q')
+	scanner := bufio.NewScanner(rd)
+	// Every field with a value is written, even in a period without changes
+	for j := 0; j < 2; j++ {
+		snap := next(scanner)
+		if len(snap.Fields) != 2 || snap.Fields[0].Value != "Prairie" ||
+			strings.Join(snap.Fields[1].Lines, ",") != "alpha,beta" {
+			t.Fatalf("unexpected snapshot %s", scanner.Text())
+		}
+	}
+	dsh.Stop()
+	// Only changed fields are written in the changes format
+	rd, wr = io.Pipe()
+	dsh = dashboard.New(nil)
+	dsh.SetHeadless(wr, 10*time.Millisecond, dashboard.FormatJSONChanges)
+	dsh.RegisterKeyVal(cnName, 0, 0, 20, "Name")
+	dsh.RegisterLine(cnLog, 0, 1, 3, "")
+	dsh.UpdateKeyVal(cnName, "Prairie")
+	dsh.UpdateLine(cnLog, "alpha")
+	go dsh.Run('q')
+	scanner = bufio.NewScanner(rd)
+	snap := next(scanner)
+	if len(snap.Fields) != 2 {
+		t.Fatalf("unexpected snapshot %s", scanner.Text())
+	}
+	dsh.UpdateLine(cnLog, "beta")
+	snap = next(scanner)
+	if len(snap.Fields) != 1 || strings.Join(snap.Fields[0].Lines, ",") != "beta" {
+		t.Fatalf("unexpected snapshot %s", scanner.Text())
+	}
+	dsh.Stop()
+	// A period that is not positive is replaced by the default
+	dsh = dashboard.New(nil)
+	dsh.SetHeadless(ioutil.Discard, 0, dashboard.FormatText)
+	errChan := make(chan error)
+	go func() {
+		errChan <- dsh.Run('q')
+	}()
+	for !dsh.Active() {
+		sleep(5)
+	}
+	dsh.Stop()
+	err := <-errChan
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Field updates are published to a long-poll manager
+func TestPublisher(t *testing.T) {
+	mgr := longpoll.New(8)
+	sim := tcell.NewSimulationScreen("")
+	dsh := dashboard.New(sim)
+	dsh.SetPublisher(mgr, "dash")
+	dsh.RegisterKeyVal(cnName, 0, 0, 20, "Name")
+	quit := runSim(t, dsh, sim)
+	dsh.UpdateKeyVal(cnName, "Natasha")
+	waitRow(t, sim, 0, "Name ....... Natasha")
+	quit()
+	list, _ := mgr.Events("dash", 0, 0)
+	if len(list) != 1 {
+		t.Fatalf("expecting one event, got %d", len(list))
+	}
+	ev, ok := list[0].Data.(dashboard.FieldEventType)
+	if !ok || ev.ID != cnName || ev.Key != "Name" || ev.Value != "Natasha" {
+		t.Fatalf("unexpected event %v", list[0].Data)
+	}
+}
+
+// Themes loaded from JSON supply the styles of marked values
+func TestTheme(t *testing.T) {
+	fileStr := filepath.Join(t.TempDir(), "theme.json")
+	err := ioutil.WriteFile(fileStr, []byte(`{"warn": {"fg": "blue", "bold": true}}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	thm, err := dashboard.LoadTheme(fileStr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim := tcell.NewSimulationScreen("")
+	dsh := dashboard.New(sim)
+	dsh.RegisterKeyVal(cnCount, 0, 0, 20, "Count")
+	quit := runSim(t, dsh, sim)
+	// waitBlue waits up to two seconds for the value to be shown in bold blue
+	waitBlue := func(sim tcell.SimulationScreen) {
+		for j := 0; j < 200; j++ {
+			fg, _, attr := cellStyle(sim, 18, 0).Decompose()
+			if fg == tcell.ColorBlue && attr&tcell.AttrBold != 0 {
+				return
+			}
+			sleep(10)
+		}
+		t.Fatalf("expecting bold blue value")
+	}
+	dsh.SetTheme(thm)
+	dsh.UpdateKeyValStyle(cnCount, "99", dashboard.StyleWarn)
+	waitRow(t, sim, 0, "Count ........... 99")
+	waitBlue(sim)
+	quit()
+	// The assigned theme is kept when the dashboard runs again
+	sim = tcell.NewSimulationScreen("")
+	dsh.SetScreen(sim)
+	quit = runSim(t, dsh, sim)
+	waitRow(t, sim, 0, "Count ........... 99")
+	waitBlue(sim)
+	quit()
+	err = ioutil.WriteFile(fileStr, []byte(`{"loud": {}}`), 0644)
+	if err == nil {
+		_, err = dashboard.LoadTheme(fileStr)
+	}
+	if err == nil {
+		t.Fatalf("expecting error for unrecognized style name")
+	}
+}
+
+// Wide characters occupy two columns in key/value and rolling line fields,
+// and long text is truncated without splitting a character
+func TestWideText(t *testing.T) {
+	sim := tcell.NewSimulationScreen("")
+	dsh := dashboard.New(sim)
+	dsh.RegisterKeyVal(cnName, 0, 0, 16, "名前")
+	dsh.RegisterLine(cnLog, 0, 1, 1, "")
+	quit := runSim(t, dsh, sim)
+	dsh.UpdateKeyVal(cnName, "東京")
+	// the second column of each wide character is reported as a blank
+	waitRow(t, sim, 0, "名 前  ...... 東 京 ")
+	dsh.UpdateLine(cnLog, strings.Repeat("漢字", 30))
+	// truncated on a character boundary to fit the 80 column screen
+	waitRow(t, sim, 1, strings.Repeat("漢 字 ", 19)+"漢 ..")
+	quit()
+}
+
+// Line entries show their level and source, and the level filter hides
+// entries below the selected level
+func TestLineLevel(t *testing.T) {
+	sim := tcell.NewSimulationScreen("")
+	dsh := dashboard.New(sim)
+	dsh.RegisterLineHistory(cnLog, 0, 0, 3, 10, "")
+	quit := runSim(t, dsh, sim)
+	dsh.UpdateLineLevel(cnLog, dashboard.LevelDebug, "", "polling")
+	dsh.UpdateLineLevel(cnLog, dashboard.LevelError, "db", "connection lost")
+	lg := stdlog.New(dsh.LineWriter(cnLog, dashboard.LevelWarn, "net"), "", 0)
+	lg.Printf("retry %d", 3)
+	waitRow(t, sim, 0, "DBG polling ")
+	waitRow(t, sim, 1, "ERR db: connection lost ")
+	waitRow(t, sim, 2, "WRN net: retry 3 ")
+	key := func(rn rune) {
+		sim.InjectKey(tcell.KeyRune, rn, tcell.ModNone)
+	}
+	sim.InjectKey(tcell.KeyTab, 0, tcell.ModNone)
+	key('l') // debug
+	key('l') // info
+	key('l') // warn
+	waitRow(t, sim, 0, "ERR db: connection lost ")
+	waitRow(t, sim, 1, "WRN net: retry 3 ")
+	waitRow(t, sim, 2, "      ")
+	key('l') // error
+	waitRow(t, sim, 1, "      ")
+	quit()
+	// Entries that replace shown ones while the view is scrolled back keep the
+	// scroll position within the remaining entries
+	sim = tcell.NewSimulationScreen("")
+	dsh = dashboard.New(sim)
+	dsh.RegisterLineHistory(cnLog, 0, 0, 2, 4, "")
+	quit = runSim(t, dsh, sim)
+	for j := 0; j < 4; j++ {
+		dsh.UpdateLineLevel(cnLog, dashboard.LevelWarn, "", fmt.Sprintf("warn %d", j))
+	}
+	waitRow(t, sim, 1, "WRN warn 3 ")
+	sim.InjectKey(tcell.KeyTab, 0, tcell.ModNone)
+	key('l') // debug
+	key('l') // info
+	key('l') // warn
+	sim.InjectKey(tcell.KeyUp, 0, tcell.ModNone)
+	sim.InjectKey(tcell.KeyUp, 0, tcell.ModNone)
+	waitRow(t, sim, 0, "WRN warn 0 ")
+	dsh.UpdateLineLevel(cnLog, dashboard.LevelDebug, "", "polling")
+	dsh.UpdateLineLevel(cnLog, dashboard.LevelDebug, "", "polling")
+	waitRow(t, sim, 0, "WRN warn 2 ")
+	waitRow(t, sim, 1, "WRN warn 3 ")
+	quit()
+}
+
+// Bound keys call their callbacks and are listed in the help overlay
+func TestBindKey(t *testing.T) {
+	sim := tcell.NewSimulationScreen("")
+	dsh := dashboard.New(sim)
+	callChan := make(chan string, 4)
+	dsh.BindKey(tcell.KeyF5, 0, tcell.ModNone, "Reload", func() { callChan <- "reload" })
+	dsh.BindKey(tcell.KeyCtrlR, 0, tcell.ModNone, "Reset counters", func() { callChan <- "reset" })
+	dsh.BindRune('p', "Pause workers", func() { callChan <- "pause" })
+	quit := runSim(t, dsh, sim)
+	expect := func(str string) {
+		select {
+		case got := <-callChan:
+			if got != str {
+				t.Fatalf("expecting callback %s, got %s", str, got)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("callback %s not called", str)
+		}
+	}
+	sim.InjectKey(tcell.KeyF5, 0, tcell.ModNone)
+	expect("reload")
+	// Control characters arrive as runes from the terminal
+	sim.InjectKey(tcell.KeyRune, 'R'-'@', tcell.ModNone)
+	expect("reset")
+	sim.InjectKey(tcell.KeyRune, 'p', tcell.ModNone)
+	expect("pause")
+	sim.InjectKey(tcell.KeyRune, '?', tcell.ModNone)
+	// Box is 26 columns wide and 7 rows high, centered on the 80x25 screen
+	row := func(nameStr, descStr string) string {
+		return fmt.Sprintf("%27s│ %-6s  %-14s │", "", nameStr, descStr)
+	}
+	waitRow(t, sim, 10, row("F5", "Reload"))
+	waitRow(t, sim, 11, row("Ctrl-R", "Reset counters"))
+	waitRow(t, sim, 13, row("q", "Quit"))
+	// Any key dismisses the overlay without triggering its binding
+	sim.InjectKey(tcell.KeyRune, 'p', tcell.ModNone)
+	waitRow(t, sim, 9, strings.Repeat(" ", 60))
+	dsh.BindRune('p', "", nil)
+	sim.InjectKey(tcell.KeyRune, 'p', tcell.ModNone)
+	quit()
+	select {
+	case str := <-callChan:
+		t.Fatalf("unexpected callback %s", str)
+	default:
+	}
+}
+
+// Prompts validate their input and menus return the selected item
+func TestPromptMenu(t *testing.T) {
+	sim := tcell.NewSimulationScreen("")
+	dsh := dashboard.New(sim)
+	quit := runSim(t, dsh, sim)
+	result := func(resChan <-chan dashboard.InputResultType) (res dashboard.InputResultType) {
+		select {
+		case res = <-resChan:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("no result")
+		}
+		return
+	}
+	typeStr := func(str string) {
+		for _, rn := range str {
+			sim.InjectKey(tcell.KeyRune, rn, tcell.ModNone)
+		}
+	}
+	resChan := dsh.Prompt("Target rate", "1", func(str string) (err error) {
+		_, err = strconv.Atoi(str)
+		if err != nil {
+			err = fmt.Errorf("not a number")
+		}
+		return
+	})
+	// Box is 40 columns wide and 4 rows high, centered on the 80x25 screen
+	typeStr("q")
+	waitRow(t, sim, 11, fmt.Sprintf("%20s│ 1q%35s│", "", ""))
+	sim.InjectKey(tcell.KeyEnter, 0, tcell.ModNone)
+	waitRow(t, sim, 12, fmt.Sprintf("%20s│ not a number%25s│", "", ""))
+	sim.InjectKey(tcell.KeyBackspace2, 0, tcell.ModNone)
+	sim.InjectKey(tcell.KeyHome, 0, tcell.ModNone)
+	typeStr("4")
+	sim.InjectKey(tcell.KeyEnter, 0, tcell.ModNone)
+	res := result(resChan)
+	if !res.OK || res.Str != "41" {
+		t.Fatalf("unexpected prompt result %v", res)
+	}
+	resChan = dsh.Menu("Mode", []string{"Fast", "Normal", "Slow"}, 0)
+	sim.InjectKey(tcell.KeyDown, 0, tcell.ModNone)
+	waitRow(t, sim, 12, fmt.Sprintf("%35s│ Normal │", ""))
+	sim.InjectKey(tcell.KeyEnter, 0, tcell.ModNone)
+	res = result(resChan)
+	if !res.OK || res.Index != 1 || res.Str != "Normal" {
+		t.Fatalf("unexpected menu result %v", res)
+	}
+	resChan = dsh.Prompt("Cancelled", "", nil)
+	sim.InjectKey(tcell.KeyEscape, 0, tcell.ModNone)
+	res = result(resChan)
+	if res.OK {
+		t.Fatalf("expecting cancelled prompt")
+	}
+	quit()
+}
+
+// Table rows are updated in place, sorted by column and removed
+func TestTable(t *testing.T) {
+	const cnTable = 100
+	sim := tcell.NewSimulationScreen("")
+	dsh := dashboard.New(sim)
+	dsh.RegisterTable(cnTable, 0, 0, 24, 4,
+		dashboard.ColumnType{Title: "Worker", Width: 7},
+		dashboard.ColumnType{Title: "Rate", Width: 6, Align: dashboard.AlignRight, Format: "%.1f"},
+		dashboard.ColumnType{Title: "State"})
+	quit := runSim(t, dsh, sim)
+	dsh.UpdateTableRow(cnTable, "a", "alpha", 12.25, "running")
+	dsh.UpdateTableRow(cnTable, "b", "beta", 3.0, "idle")
+	dsh.UpdateTableRow(cnTable, "c", "gamma", 7.5, "stopped")
+	dsh.UpdateTableRow(cnTable, "d", "delta", 0.5, "idle")
+	dsh.UpdateTableRow(cnTable, "b", "beta", 30.0, "busy")
+	waitRow(t, sim, 0, "Worker    Rate State     ")
+	waitRow(t, sim, 1, "alpha     12.2 running   ")
+	waitRow(t, sim, 2, "beta      30.0 busy      ")
+	waitRow(t, sim, 3, "gamma      7.5 stopped   ")
+	key := func(k tcell.Key, rn rune) {
+		sim.InjectKey(k, rn, tcell.ModNone)
+	}
+	key(tcell.KeyTab, 0)
+	key(tcell.KeyEnd, 0)
+	waitRow(t, sim, 3, "delta      0.5 idle    █")
+	key(tcell.KeyRune, 's') // worker ascending
+	key(tcell.KeyRune, 's') // worker descending
+	key(tcell.KeyRune, 's') // rate ascending
+	waitRow(t, sim, 0, "Worker   Rate↑ State   ")
+	waitRow(t, sim, 2, "alpha     12.2 running │")
+	waitRow(t, sim, 3, "beta      30.0 busy    █")
+	dsh.RemoveTableRow(cnTable, "a")
+	waitRow(t, sim, 1, "delta      0.5 idle    │")
+	waitRow(t, sim, 3, "beta      30.0 busy    █")
+	quit()
+}
+
+// Updates are queued without blocking and those that overflow are counted
+func TestStats(t *testing.T) {
+	const cnStats = 100
+	sim := tcell.NewSimulationScreen("")
+	dsh := dashboard.New(sim)
+	dsh.RegisterKeyVal(cnCount, 0, 0, 20, "Count")
+	dsh.RegisterStats(cnStats, 0, 1, 60, "Stats")
+	dsh.SetFrameRate(10)
+	// Updates are queued without blocking before the dashboard runs
+	for j := 0; j < 300; j++ {
+		dsh.UpdateKeyVal(cnCount, strconv.Itoa(j))
+	}
+	if dsh.Dropped() != 44 {
+		t.Fatalf("expecting 44 dropped updates, got %d", dsh.Dropped())
+	}
+	quit := runSim(t, dsh, sim)
+	waitRow(t, sim, 0, "Count .......... 255")
+	waitRow(t, sim, 1, "Stats ..... 256 received, 44 dropped, 0 queued, 0.0 frames/s")
+	quit()
+}
+
+// A stopped dashboard keeps its fields and can run again on another screen
+func TestRestart(t *testing.T) {
+	simA := tcell.NewSimulationScreen("")
+	dsh := dashboard.New(simA)
+	dsh.RegisterKeyVal(cnName, 0, 0, 20, "Name")
+	ctx, cancel := context.WithCancel(context.Background())
+	errChan := make(chan error)
+	go func() {
+		errChan <- dsh.RunContext(ctx, 'q')
+	}()
+	for !dsh.Active() {
+		sleep(5)
+	}
+	dsh.UpdateKeyVal(cnName, "first")
+	waitRow(t, simA, 0, "Name ......... first")
+	cancel()
+	err := <-errChan
+	if err != context.Canceled {
+		t.Fatalf("expecting cancellation, got %v", err)
+	}
+	if dsh.Active() || dsh.Updateable() {
+		t.Fatalf("expecting stopped dashboard")
+	}
+	// Updates sent while stopped are shown when the dashboard runs again.
+	// Control requests neither block nor are discarded when the update
+	// channel is full.
+	for j := 0; j < 300; j++ {
+		dsh.UpdateKeyVal(cnName, "second")
+	}
+	dsh.SetKey(cnName, "Key")
+	dsh.Hide(cnName)
+	dsh.Show(cnName)
+	if dsh.Snapshot(ioutil.Discard, dashboard.SnapshotText) == nil {
+		t.Fatalf("expecting snapshot error while stopped")
+	}
+	simB := tcell.NewSimulationScreen("")
+	dsh.SetScreen(simB)
+	go func() {
+		errChan <- dsh.Run('q')
+	}()
+	for !dsh.Active() {
+		sleep(5)
+	}
+	waitRow(t, simB, 0, "Key ......... second")
+	if !dsh.Updateable() {
+		t.Fatalf("expecting updateable dashboard")
+	}
+	dsh.Stop()
+	err = <-errChan
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Fields can be relabeled, hidden, shown again and unregistered
+func TestUnregister(t *testing.T) {
+	sim := tcell.NewSimulationScreen("")
+	dsh := dashboard.New(sim)
+	dsh.RegisterKeyVal(cnName, 0, 0, 20, "Name")
+	dsh.RegisterKeyVal(cnCount, 0, 0, 20, "Count")
+	dsh.SetLayout(dashboard.LayoutType{Split: "cols", Children: []dashboard.LayoutType{
+		{Size: 20, Fields: []int{cnName, cnCount}}, {}}})
+	quit := runSim(t, dsh, sim)
+	dsh.UpdateKeyVal(cnName, "Prairie")
+	dsh.UpdateKeyVal(cnCount, "12")
+	waitRow(t, sim, 1, "Count ........... 12")
+	dsh.SetKey(cnCount, "Total")
+	waitRow(t, sim, 1, "Total ........... 12")
+	// Hidden fields are skipped by the layout
+	dsh.Hide(cnName)
+	waitRow(t, sim, 0, "Total ........... 12")
+	waitRow(t, sim, 1, strings.Repeat(" ", 20))
+	dsh.UpdateKeyVal(cnName, "Dakota")
+	dsh.Show(cnName)
+	waitRow(t, sim, 0, "Name ........ Dakota")
+	dsh.Unregister(cnName)
+	waitRow(t, sim, 0, "Total ........... 12")
+	dsh.UpdateKeyVal(cnName, "Plains")
+	dsh.Unregister(cnCount)
+	waitRow(t, sim, 0, strings.Repeat(" ", 20))
+	quit()
+}
+
+// Recorded updates are replayed one step at a time on another dashboard
+func TestRecordReplay(t *testing.T) {
+	const cnTable = 100
+	register := func(sim tcell.SimulationScreen) (dsh *dashboard.Dashboard) {
+		dsh = dashboard.New(sim)
+		dsh.RegisterKeyVal(cnName, 0, 0, 20, "Name")
+		dsh.RegisterLine(cnLog, 0, 1, 1, "")
+		dsh.RegisterTable(cnTable, 0, 2, 20, 2,
+			dashboard.ColumnType{Title: "City", Width: 8},
+			dashboard.ColumnType{Title: "Temp", Format: "%.1f"})
+		return
+	}
+	var buf bytes.Buffer
+	simA := tcell.NewSimulationScreen("")
+	dshA := register(simA)
+	dshA.SetRecorder(&buf)
+	quit := runSim(t, dshA, simA)
+	dshA.UpdateKeyVal(cnName, "Osage")
+	dshA.UpdateLine(cnLog, "Starting")
+	dshA.UpdateTableRow(cnTable, "ks", "Wichita", 21.25)
+	waitRow(t, simA, 3, "Wichita  21.2       ")
+	quit()
+	data := buf.Bytes()
+	var recList []dashboard.RecordType
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for dec.More() {
+		var rec dashboard.RecordType
+		err := dec.Decode(&rec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		recList = append(recList, rec)
+	}
+	if len(recList) != 3 || recList[0].Str != "Osage" || len(recList[2].Cells) != 2 ||
+		recList[2].Cells[1] != "21.2" {
+		t.Fatalf("unexpected recording %v", recList)
+	}
+	simB := tcell.NewSimulationScreen("")
+	dshB := register(simB)
+	quit = runSim(t, dshB, simB)
+	errChan := make(chan error)
+	go func() {
+		errChan <- dshB.Replay(context.Background(), bytes.NewReader(data), dashboard.ReplayStep)
+	}()
+	dshB.Step()
+	waitRow(t, simB, 0, "Name ......... Osage")
+	dshB.Step()
+	waitRow(t, simB, 1, "Starting            ")
+	dshB.Step()
+	waitRow(t, simB, 3, "Wichita  21.2       ")
+	err := <-errChan
+	if err != nil {
+		t.Fatal(err)
+	}
+	quit()
+}
+
+// Snapshots of the screen are written in each format and on a bound key
+func TestSnapshot(t *testing.T) {
+	sim := tcell.NewSimulationScreen("")
+	dsh := dashboard.New(sim)
+	dsh.RegisterKeyVal(cnName, 0, 0, 20, "Name")
+	quit := runSim(t, dsh, sim)
+	dsh.UpdateKeyVal(cnName, "Sandhills")
+	waitRow(t, sim, 0, "Name ..... Sandhills")
+	check := func(format int, expStr string) {
+		var buf bytes.Buffer
+		err := dsh.Snapshot(&buf, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(buf.String(), expStr) {
+			t.Fatalf("expecting %q in snapshot, got %q", expStr, buf.String())
+		}
+	}
+	check(dashboard.SnapshotText, "Name ..... Sandhills\n\n")
+	check(dashboard.SnapshotANSI, "\x1b[0;38;2;255;255;0mName ..... \x1b[0;38;2;255;255;255mSandhills\x1b[0m\n")
+	check(dashboard.SnapshotHTML, `<span style="color:#ffff00">Name ..... </span>`)
+	check(dashboard.SnapshotSVG, `fill="#ffffff" textLength="81" lengthAdjust="spacingAndGlyphs" xml:space="preserve">Sandhills</text>`)
+	dir, err := ioutil.TempDir("", "dashboard")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	dsh.BindSnapshot(tcell.KeyCtrlS, 0, tcell.ModNone, dashboard.SnapshotText, dir)
+	sim.InjectKey(tcell.KeyCtrlS, 0, tcell.ModCtrl)
+	var data []byte
+	for j := 0; j < 100 && !strings.HasPrefix(string(data), "Name"); j++ {
+		sleep(10)
+		list, _ := filepath.Glob(filepath.Join(dir, "dashboard-*.txt"))
+		if len(list) == 1 {
+			data, _ = ioutil.ReadFile(list[0])
+		}
+	}
+	if !strings.HasPrefix(string(data), "Name ..... Sandhills\n") {
+		t.Fatalf("unexpected snapshot file %q", data)
+	}
+	quit()
+	err = dsh.Snapshot(ioutil.Discard, dashboard.SnapshotText)
+	if err == nil {
+		t.Fatalf("expecting error from stopped dashboard")
+	}
+}
+
+// Alert rules style values, report to the alert line and detect stale values
+func TestAlert(t *testing.T) {
+	sim := tcell.NewSimulationScreen("")
+	dsh := dashboard.New(sim)
+	dsh.RegisterKeyVal(cnCount, 0, 0, 20, "Temp")
+	dsh.RegisterKeyVal(cnName, 0, 1, 20, "State")
+	dsh.RegisterLine(cnLog, 0, 2, 3, "")
+	dsh.SetAlertLine(cnLog)
+	err := dsh.AddAlert(cnCount, dashboard.AlertType{Kind: dashboard.AlertAbove, Limit: 100,
+		Style: dashboard.StyleError, Bell: true, Level: dashboard.LevelError})
+	if err == nil {
+		err = dsh.AddAlert(cnName, dashboard.AlertType{Kind: dashboard.AlertMatch, Pattern: "^fail",
+			Style: dashboard.StyleWarn, Flash: true, Level: dashboard.LevelWarn})
+	}
+	if err == nil {
+		err = dsh.AddAlert(cnName, dashboard.AlertType{Kind: dashboard.AlertStale, Age: 300 * time.Millisecond,
+			Style: dashboard.StyleDim})
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dsh.AddAlert(cnName, dashboard.AlertType{Kind: dashboard.AlertMatch, Pattern: "("}) == nil {
+		t.Fatalf("expecting error from invalid pattern")
+	}
+	valFg := func(y int) tcell.Color {
+		fg, _, _ := cellStyle(sim, 19, y).Decompose()
+		return fg
+	}
+	quit := runSim(t, dsh, sim)
+	dsh.UpdateKeyVal(cnCount, "98")
+	dsh.UpdateKeyVal(cnName, "ready")
+	waitRow(t, sim, 1, "State ........ ready")
+	if valFg(0) != tcell.ColorWhite {
+		t.Fatalf("expecting normal value style")
+	}
+	dsh.UpdateKeyVal(cnCount, "104")
+	waitRow(t, sim, 2, "ERR Temp 104 above 100")
+	if valFg(0) != tcell.ColorRed {
+		t.Fatalf("expecting error value style")
+	}
+	dsh.UpdateKeyVal(cnName, "failed")
+	waitRow(t, sim, 3, "WRN State failed matches ^fail")
+	// Unchanged value becomes stale once the match no longer holds
+	dsh.ClearAlerts(cnCount)
+	dsh.UpdateKeyVal(cnCount, "105")
+	dsh.UpdateKeyVal(cnName, "idle")
+	waitRow(t, sim, 1, "State ......... idle")
+	for j := 0; j < 100 && valFg(1) != tcell.ColorGray; j++ {
+		sleep(10)
+	}
+	if valFg(1) != tcell.ColorGray || valFg(0) != tcell.ColorWhite {
+		t.Fatalf("expecting dim stale value and normal cleared value")
+	}
+	quit()
+}
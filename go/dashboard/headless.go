@@ -0,0 +1,232 @@
+package dashboard
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/jung-kurt/etc/go/util"
+)
+
+// Headless snapshot formats
+const (
+	FormatText        = iota // one "key: value" line per field
+	FormatJSON               // one JSON object per snapshot
+	FormatTextChanges        // as FormatText, but only fields that have changed
+	FormatJSONChanges        // as FormatJSON, but only fields that have changed
+)
+
+const cnHeadlessPeriod = time.Second // snapshot period used when none is specified
+
+// SetHeadless arranges for the dashboard to write snapshots of its fields to w
+// rather than drawing them on a screen. This allows an application to use the
+// same Register and Update calls whether or not a terminal is present. A
+// snapshot of every field that has a value is written each period; for
+// rolling line fields, the entries that fit in the field's height are
+// included. With FormatText, each field is written as a line made up of the
+// time, the field's key and its value. With FormatJSON, each snapshot is a
+// single line holding an object with "time" and "fields" members.
+// FormatTextChanges and FormatJSONChanges are similar, but a snapshot includes
+// only the fields that have changed since the previous one, each rolling line
+// entry is reported once, and nothing is written for a period without
+// changes. If period is not positive, one second is used. In headless mode, Run() ignores its quit
+// runes and returns when Stop() is called or its context is done. This method
+// must be called before Run().
+func (dsh *Dashboard) SetHeadless(w io.Writer, period time.Duration, format int) {
+	if period <= 0 {
+		period = cnHeadlessPeriod
+	}
+	dsh.out = w
+	dsh.period = period
+	dsh.format = format
+}
+
+// fieldText returns the value of the field specified by fld as a string. For
+// rolling line fields, this is the most recent entry.
+func fieldText(fld fieldPtrType) (str string) {
+	switch fld.item {
+	case itemKeyVal, itemStats:
+		str = fld.valStr
+	case itemLine:
+		str = lineText(fld, lineRec(fld, fld.count-1))
+	case itemProgress:
+		str = fmt.Sprintf("%d%%", int(fld.val*100))
+		if fld.valStr != "" {
+			str += " " + fld.valStr
+		}
+	case itemGauge:
+		str = f3(fld.val)
+	case itemWalk:
+		str = util.StrIf(fld.ok, "ok", "fail")
+	case itemChart:
+		str = f3(chartSample(fld, fld.count-1))
+	case itemTable:
+		str = tableText(fld)
+	}
+	return
+}
+
+// fieldSet returns true if the field specified by fld has a value that can be
+// written to a headless snapshot.
+func fieldSet(fld fieldPtrType) bool {
+	switch fld.item {
+	case itemHeader, itemHeaderLine:
+		return false
+	case itemTable:
+		return len(fld.rowList) > 0
+	}
+	return fld.count > 0
+}
+
+// snapshotPut writes the fields of the dashboard to the headless writer. In
+// the changes formats, only fields that have changed since the previous
+// snapshot are written.
+func (dsh *Dashboard) snapshotPut(tm time.Time) (err error) {
+	var list []fieldPtrType
+	var bld util.JSONBuilder
+
+	changes := dsh.format == FormatTextChanges || dsh.format == FormatJSONChanges
+	asJSON := dsh.format == FormatJSON || dsh.format == FormatJSONChanges
+	for _, fieldPtr := range dsh.fieldList() {
+		if (changes && fieldPtr.changed) || (!changes && fieldSet(fieldPtr)) {
+			list = append(list, fieldPtr)
+		}
+	}
+	if changes && len(list) == 0 {
+		return
+	}
+	dsh.buf.Reset()
+	if asJSON {
+		bld.ObjectOpen()
+		bld.KeyElement("time", tm.Format(time.RFC3339))
+		bld.KeyArrayOpen("fields")
+	}
+	tmStr := tm.Format("2006-01-02 15:04:05")
+	for _, fld := range list {
+		if asJSON {
+			bld.ObjectOpen()
+			bld.KeyElement("id", fld.id)
+			if fld.str != "" {
+				bld.KeyElement("key", fld.str)
+			}
+		}
+		if fld.item == itemLine {
+			if asJSON {
+				bld.KeyArrayOpen("lines")
+			}
+			first := fld.count - fld.fresh
+			if !changes {
+				first = fld.count - fld.ht
+				if first < 0 {
+					first = 0
+				}
+			}
+			for j := first; j < fld.count; j++ {
+				if asJSON {
+					bld.Element(lineText(fld, lineRec(fld, j)))
+				} else {
+					fmt.Fprintf(dsh.buf, "%s %s\n", tmStr, lineText(fld, lineRec(fld, j)))
+				}
+			}
+			if asJSON {
+				bld.ArrayClose()
+			}
+		} else if asJSON {
+			bld.KeyElement("value", fieldText(fld))
+		} else {
+			fmt.Fprintf(dsh.buf, "%s %s: %s\n", tmStr, fld.str, fieldText(fld))
+		}
+		if asJSON {
+			bld.ObjectClose()
+		}
+		fld.changed = false
+		fld.fresh = 0
+	}
+	if asJSON {
+		bld.ArrayClose()
+		bld.ObjectClose()
+		err = bld.Error()
+		if err == nil {
+			dsh.buf.WriteString(bld.String())
+			dsh.buf.WriteString("\n")
+		}
+	}
+	if err == nil {
+		_, err = io.WriteString(dsh.out, dsh.buf.String())
+	}
+	return
+}
+
+// runHeadless is the headless counterpart to run(). Field values are updated
+// as they arrive and written out periodically. It returns when ctx is done or
+// a snapshot cannot be written.
+func (dsh *Dashboard) runHeadless(ctx context.Context) (err error) {
+	dsh.statTm = time.Now()
+	tick := time.NewTicker(dsh.period)
+	defer tick.Stop()
+	alertCheck := func(fieldPtr fieldPtrType, tm time.Time) {
+		_, lineFld := dsh.alertCheck(fieldPtr, tm)
+		if lineFld != nil {
+			lineFld.changed = true
+			if lineFld.fresh < lineFld.count {
+				lineFld.fresh++
+			}
+		}
+	}
+	loop := true
+	for loop && err == nil {
+		select {
+		case <-dsh.ctrlChan:
+			for _, up := range dsh.ctrlTake() {
+				switch up.id {
+				case updateModal:
+					up.modal.resChan <- InputResultType{Index: -1}
+				case updateSnapshot:
+					up.snap <- nil
+				case updateUnregister, updateVisible, updateKeyStr, updateAlert:
+					dsh.fieldChange(up)
+				}
+			}
+		case up := <-dsh.updateChan:
+			dsh.fieldMtx.Lock()
+			fieldPtr, ok := dsh.fieldMap[up.id]
+			dsh.fieldMtx.Unlock()
+			if ok {
+				dsh.received++
+				fieldUpdate(fieldPtr, up)
+				dsh.record(fieldPtr, up)
+				dsh.publish(fieldPtr)
+				fieldPtr.changed = true
+				if fieldPtr.item == itemLine && fieldPtr.fresh < fieldPtr.count {
+					fieldPtr.fresh++
+				}
+				if len(fieldPtr.alertList) > 0 {
+					alertCheck(fieldPtr, time.Now())
+				}
+			}
+		case <-ctx.Done():
+			loop = false
+		case tm := <-tick.C:
+			for _, fieldPtr := range dsh.statsUpdate(tm) {
+				fieldPtr.changed = true
+			}
+			for _, fieldPtr := range dsh.fieldList() {
+				if len(fieldPtr.alertList) > 0 {
+					alertCheck(fieldPtr, tm)
+				}
+			}
+			err = dsh.snapshotPut(tm)
+		}
+	}
+	if err == nil {
+		err = dsh.snapshotPut(time.Now())
+	}
+	return
+}
+
+// SetHeadless arranges for the default dashboard to write snapshots to w
+// rather than draw on a screen. See Dashboard.SetHeadless() for details.
+func SetHeadless(w io.Writer, period time.Duration, format int) {
+	std.SetHeadless(w, period, format)
+}
@@ -0,0 +1,192 @@
+package dashboard
+
+import (
+	"github.com/gdamore/tcell"
+	"github.com/jung-kurt/etc/go/util"
+)
+
+// keyBindingType associates a key with an application callback.
+type keyBindingType struct {
+	key     tcell.Key     // normalized key code
+	rn      rune          // rune if key is tcell.KeyRune
+	mod     tcell.ModMask // modifiers
+	descStr string        // description shown in help overlay
+	fnc     func()        // application callback
+}
+
+// keyMatch returns true if the key event specified by ev corresponds to the
+// binding specified by bnd. Shift is ignored for runes since it is implied by
+// the rune itself, and Ctrl is ignored for control keys since it is implied by
+// the key code.
+func keyMatch(bnd *keyBindingType, ev *tcell.EventKey) bool {
+	var implied tcell.ModMask
+	if ev.Key() != bnd.key {
+		return false
+	}
+	if bnd.key == tcell.KeyRune {
+		if ev.Rune() != bnd.rn {
+			return false
+		}
+		implied = tcell.ModShift
+	} else if bnd.key < tcell.KeyRune {
+		implied = tcell.ModCtrl
+	}
+	return ev.Modifiers()&^implied == bnd.mod&^implied
+}
+
+// keyName returns a readable name, for example "F5", "Ctrl-R" or "Alt+x",
+// for the key specified by key, rn and mod.
+func keyName(key tcell.Key, rn rune, mod tcell.ModMask) string {
+	ev := tcell.NewEventKey(key, rn, mod)
+	if ev.Key() == tcell.KeyRune {
+		str := string(rn)
+		if rn == ' ' {
+			str = "Space"
+		}
+		if mod&tcell.ModAlt != 0 {
+			str = "Alt+" + str
+		}
+		return str
+	}
+	return ev.Name()
+}
+
+// keyCall invokes, in a new goroutine, the callback bound to the key event
+// specified by ev. handled is false if no binding applies.
+func (dsh *Dashboard) keyCall(ev *tcell.EventKey) (handled bool) {
+	var fnc func()
+	dsh.keyMtx.Lock()
+	for j := range dsh.keyList {
+		if keyMatch(&dsh.keyList[j], ev) {
+			fnc = dsh.keyList[j].fnc
+		}
+	}
+	dsh.keyMtx.Unlock()
+	if fnc != nil {
+		go fnc()
+		handled = true
+	}
+	return
+}
+
+// helpList returns the key names and descriptions shown in the help overlay.
+func (dsh *Dashboard) helpList() (nameList, descList []string) {
+	add := func(nameStr, descStr string) {
+		nameList = append(nameList, nameStr)
+		descList = append(descList, descStr)
+	}
+	dsh.keyMtx.Lock()
+	for _, bnd := range dsh.keyList {
+		add(keyName(bnd.key, bnd.rn, bnd.mod), bnd.descStr)
+	}
+	dsh.keyMtx.Unlock()
+	var lineOK, tableOK bool
+	for _, fld := range dsh.focusList() {
+		lineOK = lineOK || fld.item == itemLine
+		tableOK = tableOK || fld.item == itemTable
+	}
+	if lineOK || tableOK {
+		add("Tab", "Focus next log or table")
+	}
+	if lineOK {
+		add("/ n N", "Search focused log")
+		add("l", "Filter focused log by level")
+	}
+	if tableOK {
+		add("s", "Sort focused table")
+	}
+	for _, rn := range dsh.quitList {
+		if rn == 27 {
+			add("Esc", "Quit")
+		} else {
+			add(keyName(tcell.KeyRune, rn, tcell.ModNone), "Quit")
+		}
+	}
+	add("?", "Show this help")
+	return
+}
+
+// helpPut draws a box centered on the screen that lists the key bindings.
+func (dsh *Dashboard) helpPut(scrWd, scrHt int) {
+	var nameWd, descWd int
+	thm := &dsh.theme
+	nameList, descList := dsh.helpList()
+	for j := range nameList {
+		if wd := util.StrWidth(nameList[j]); wd > nameWd {
+			nameWd = wd
+		}
+		if wd := util.StrWidth(descList[j]); wd > descWd {
+			descWd = wd
+		}
+	}
+	wd := nameWd + descWd + 6
+	ht := len(nameList) + 2
+	if wd > scrWd {
+		wd = scrWd
+	}
+	if wd > cnMaxWidth {
+		wd = cnMaxWidth
+	}
+	if wd < 2 || ht > scrHt {
+		return
+	}
+	x := (scrWd - wd) / 2
+	y := (scrHt - ht) / 2
+	lim := x + wd - 1
+	dsh.boxPut(thm.Key, thm.Banner, x, y, wd, ht, scrWd, "Keys")
+	for j := range nameList {
+		left := dsh.put(thm.Key, x+1, y+1+j, lim, " ", util.StrPad(nameList[j], nameWd), "  ")
+		dsh.put(thm.Value, left, y+1+j, lim, util.StrPad(descList[j], descWd), " ")
+	}
+}
+
+// BindKey arranges for the function specified by fnc to be called when the
+// key specified by key, rn and mod is pressed while the dashboard is running.
+// For function keys and control combinations, key identifies the key (for
+// example, tcell.KeyF5 or tcell.KeyCtrlR) and rn is ignored. For printable
+// keys, key is tcell.KeyRune and rn is the character. mod holds any
+// additional modifiers such as tcell.ModAlt. The binding and its description,
+// specified by descStr, are listed in the help overlay that is shown when '?'
+// is pressed. fnc is called in its own goroutine, so it may take time and may
+// update the dashboard. Binding a key again replaces its earlier binding; a
+// nil value for fnc removes it. Bindings take precedence over quit runes and
+// the navigation keys of rolling line fields, but not over the entry of a
+// search pattern.
+func (dsh *Dashboard) BindKey(key tcell.Key, rn rune, mod tcell.ModMask, descStr string, fnc func()) {
+	ev := tcell.NewEventKey(key, rn, mod)
+	bnd := keyBindingType{key: ev.Key(), rn: ev.Rune(), mod: ev.Modifiers(), descStr: descStr, fnc: fnc}
+	if bnd.key != tcell.KeyRune {
+		bnd.rn = 0
+	}
+	dsh.keyMtx.Lock()
+	list := dsh.keyList[:0]
+	for _, old := range dsh.keyList {
+		if old.key != bnd.key || old.rn != bnd.rn || old.mod != bnd.mod {
+			list = append(list, old)
+		}
+	}
+	if fnc != nil {
+		list = append(list, bnd)
+	}
+	dsh.keyList = list
+	dsh.keyMtx.Unlock()
+}
+
+// BindRune arranges for the function specified by fnc to be called when the
+// key for the character specified by rn is pressed. See BindKey() for
+// details.
+func (dsh *Dashboard) BindRune(rn rune, descStr string, fnc func()) {
+	dsh.BindKey(tcell.KeyRune, rn, tcell.ModNone, descStr, fnc)
+}
+
+// BindKey binds a key to a callback for the default dashboard. See
+// Dashboard.BindKey() for details.
+func BindKey(key tcell.Key, rn rune, mod tcell.ModMask, descStr string, fnc func()) {
+	std.BindKey(key, rn, mod, descStr, fnc)
+}
+
+// BindRune binds a character key to a callback for the default dashboard. See
+// Dashboard.BindKey() for details.
+func BindRune(rn rune, descStr string, fnc func()) {
+	std.BindRune(rn, descStr, fnc)
+}
@@ -0,0 +1,213 @@
+package dashboard
+
+import (
+	"github.com/gdamore/tcell"
+	"github.com/jung-kurt/etc/go/util"
+)
+
+// LayoutType describes the placement of dashboard fields as a tree of panels.
+// The screen is assigned to the root panel. A panel divides its area among its
+// children, either stacked from top to bottom (Split is "rows" or empty) or
+// placed from left to right (Split is "cols"). Along that direction, a child
+// with a nonzero Size receives exactly that many cells; the remaining space is
+// shared among the other children in proportion to their Weight (a zero weight
+// counts as one), with each receiving at least Min cells. The fields listed in
+// Fields are stacked from the top of the panel, each using the panel's full
+// width and its own natural height; hidden fields are skipped, and fields that
+// do not fit in the panel are not shown. A panel with Border set is surrounded
+// by a box with its Title, if any, shown in the top edge. Positions are
+// recalculated whenever the screen is resized.
+type LayoutType struct {
+	Split    string       `json:"split,omitempty"`
+	Size     int          `json:"size,omitempty"`
+	Min      int          `json:"min,omitempty"`
+	Weight   int          `json:"weight,omitempty"`
+	Border   bool         `json:"border,omitempty"`
+	Title    string       `json:"title,omitempty"`
+	Fields   []int        `json:"fields,omitempty"`
+	Children []LayoutType `json:"children,omitempty"`
+}
+
+// LoadLayout reads a JSON-encoded layout from the file specified by fileStr.
+func LoadLayout(fileStr string) (lay LayoutType, err error) {
+	err = util.JSONGetFile(fileStr, &lay)
+	return
+}
+
+// fieldHeight returns the number of rows occupied by the field specified by
+// fld.
+func fieldHeight(fld fieldPtrType) int {
+	switch fld.item {
+	case itemWalk:
+		return cnWalkWidth
+	case itemChart:
+		return fld.ht + 1
+	case itemLine, itemTable:
+		return fld.ht
+	}
+	return 1
+}
+
+func layoutWeight(lay *LayoutType) int {
+	if lay.Weight > 0 {
+		return lay.Weight
+	}
+	return 1
+}
+
+// layoutSizes divides total cells among the panels specified by list. Panels
+// with a fixed size are given it first. The remaining space is divided by
+// weight; panels whose share falls below their minimum are given the minimum
+// and the division is repeated among the others. If the fixed sizes and
+// minimums exceed total, the last panels are reduced so that the sizes add up
+// to no more than total.
+func layoutSizes(list []LayoutType, total int) (sizes []int) {
+	count := len(list)
+	sizes = make([]int, count)
+	fixed := make([]bool, count)
+	for j := range list {
+		if list[j].Size > 0 {
+			sizes[j] = list[j].Size
+			fixed[j] = true
+		}
+	}
+	loop := true
+	for loop {
+		remain := total
+		weightSum := 0
+		for j := range list {
+			if fixed[j] {
+				remain -= sizes[j]
+			} else {
+				weightSum += layoutWeight(&list[j])
+			}
+		}
+		if remain < 0 {
+			remain = 0
+		}
+		loop = false
+		if weightSum > 0 {
+			given := 0
+			last := 0
+			for j := range list {
+				if !fixed[j] {
+					sizes[j] = remain * layoutWeight(&list[j]) / weightSum
+					given += sizes[j]
+					last = j
+				}
+			}
+			sizes[last] += remain - given
+			for j := range list {
+				if !fixed[j] && sizes[j] < list[j].Min {
+					sizes[j] = list[j].Min
+					fixed[j] = true
+					loop = true
+				}
+			}
+		}
+	}
+	used := 0
+	for j := range sizes {
+		if sizes[j] > total-used {
+			sizes[j] = total - used
+			if sizes[j] < 0 {
+				sizes[j] = 0
+			}
+		}
+		used += sizes[j]
+	}
+	return
+}
+
+// boxPut draws a box with the specified title in its top edge. Nothing is
+// drawn to the right of lim.
+func (dsh *Dashboard) boxPut(boxSt, titleSt tcell.Style, x, y, wd, ht, lim int, title string) {
+	rt := x + wd - 1
+	bt := y + ht - 1
+	dsh.put(boxSt, x, y, lim, string(tcell.RuneULCorner), string(lines[:wd-2]), string(tcell.RuneURCorner))
+	dsh.put(boxSt, x, bt, lim, string(tcell.RuneLLCorner), string(lines[:wd-2]), string(tcell.RuneLRCorner))
+	for row := y + 1; row < bt; row++ {
+		dsh.put(boxSt, x, row, lim, string(tcell.RuneVLine))
+		dsh.put(boxSt, rt, row, lim, string(tcell.RuneVLine))
+	}
+	if title != "" && wd > 4 {
+		titleLim := rt - 1
+		if titleLim > lim {
+			titleLim = lim
+		}
+		dsh.put(titleSt, x+2, y, titleLim, " ", title, " ")
+	}
+}
+
+// layoutPut assigns the rectangle specified by x, y, wd and ht to the panel
+// specified by lay, draws its border, and positions its fields and children.
+func (dsh *Dashboard) layoutPut(lay *LayoutType, x, y, wd, ht, scrWd int) {
+	if wd > cnMaxWidth {
+		wd = cnMaxWidth
+	}
+	if lay.Border && wd >= 2 && ht >= 2 {
+		dsh.boxPut(dsh.theme.Key, dsh.theme.Banner, x, y, wd, ht, scrWd, lay.Title)
+		x++
+		y++
+		wd -= 2
+		ht -= 2
+	}
+	top := y
+	full := false
+	dsh.fieldMtx.Lock()
+	for _, id := range lay.Fields {
+		fieldPtr, ok := dsh.fieldMap[id]
+		if ok && !fieldPtr.hidden {
+			// A zero width would extend the field to the edge of the screen,
+			// and fields below the panel would be drawn over its neighbours
+			full = full || wd <= 0 || top+fieldHeight(fieldPtr) > y+ht
+			fieldPtr.squeezed = full
+			if !fieldPtr.squeezed {
+				fieldPtr.x = x
+				fieldPtr.y = top
+				fieldPtr.wd = wd
+				top += fieldHeight(fieldPtr)
+			}
+		}
+	}
+	dsh.fieldMtx.Unlock()
+	if len(lay.Children) > 0 {
+		cols := lay.Split == "cols"
+		total := ht
+		if cols {
+			total = wd
+		}
+		for j, size := range layoutSizes(lay.Children, total) {
+			if cols {
+				dsh.layoutPut(&lay.Children[j], x, y, size, ht, scrWd)
+				x += size
+			} else {
+				dsh.layoutPut(&lay.Children[j], x, y, wd, size, scrWd)
+				y += size
+			}
+		}
+	}
+}
+
+// layoutApply positions fields according to the current layout for a screen
+// of the specified size.
+func (dsh *Dashboard) layoutApply(scrWd, scrHt int) {
+	for _, fieldPtr := range dsh.fieldList() {
+		fieldPtr.squeezed = false
+	}
+	dsh.layoutPut(dsh.layout, 0, 0, scrWd, scrHt, scrWd)
+}
+
+// SetLayout assigns lay to the dashboard. The positions and widths of the
+// fields named in lay are recalculated from the screen size now and every
+// time the screen is resized. Fields that are not named in lay keep their
+// registered positions.
+func (dsh *Dashboard) SetLayout(lay LayoutType) {
+	dsh.control(updateType{id: updateLayout, lay: &lay})
+}
+
+// SetLayout assigns a layout to the default dashboard. See
+// Dashboard.SetLayout() for details.
+func SetLayout(lay LayoutType) {
+	std.SetLayout(lay)
+}
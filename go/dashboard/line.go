@@ -0,0 +1,534 @@
+package dashboard
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell"
+	"github.com/jung-kurt/etc/go/util"
+)
+
+// Severity levels of rolling line entries used with UpdateLineLevel()
+const (
+	LevelNone  = iota // untagged entry, treated as LevelInfo when filtering
+	LevelDebug        // diagnostic detail
+	LevelInfo         // routine information
+	LevelWarn         // condition that warrants attention
+	LevelError        // failure
+)
+
+// levelTags holds the tag shown for each severity level.
+var levelTags = [...]string{"", "DBG ", "INF ", "WRN ", "ERR "}
+
+// lineRecType is an entry of a rolling line field.
+type lineRecType struct {
+	tm     time.Time // time entry was added
+	level  int       // severity, LevelNone through LevelError
+	srcStr string    // source of entry, empty for none
+	msgStr string    // message
+}
+
+// lineLevel returns the level used to filter the entry specified by rec.
+func lineLevel(rec *lineRecType) int {
+	if rec.level <= LevelNone || rec.level > LevelError {
+		return LevelInfo
+	}
+	return rec.level
+}
+
+// lineAdd appends rec to the history of the rolling line field specified by
+// fld. If the view is scrolled back, it remains on the same entries as far as
+// possible; the scroll position is limited again because the entry that was
+// replaced may have been shown.
+func lineAdd(fld fieldPtrType, rec lineRecType) {
+	size := len(fld.recList)
+	if fld.count < size {
+		fld.count++
+	}
+	fld.recList[fld.pos] = rec
+	fld.pos++
+	if fld.pos >= size {
+		fld.pos = 0
+	}
+	if fld.scroll > 0 {
+		delta := 0
+		if lineLevel(&rec) >= fld.minLevel {
+			delta = 1
+		}
+		lineScroll(fld, delta)
+	}
+}
+
+// lineRec returns history entry j of the rolling line field specified by fld,
+// where zero is the oldest retained entry.
+func lineRec(fld fieldPtrType, j int) *lineRecType {
+	k := fld.pos + j
+	if k >= fld.count {
+		k -= fld.count
+	}
+	return &fld.recList[k]
+}
+
+// lineText returns the entry specified by rec of the rolling line field
+// specified by fld as a single string made up of its timestamp, level tag,
+// source and message.
+func lineText(fld fieldPtrType, rec *lineRecType) string {
+	var buf strings.Builder
+	if fld.timeFmtStr != "" {
+		buf.WriteString(rec.tm.Format(fld.timeFmtStr))
+	}
+	if rec.level > LevelNone && rec.level <= LevelError {
+		buf.WriteString(levelTags[rec.level])
+	}
+	if rec.srcStr != "" {
+		buf.WriteString(rec.srcStr)
+		buf.WriteString(": ")
+	}
+	buf.WriteString(rec.msgStr)
+	return buf.String()
+}
+
+// lineShown returns the history positions, oldest first, of the entries of
+// the rolling line field specified by fld that pass its level filter.
+func lineShown(fld fieldPtrType) (list []int) {
+	list = make([]int, 0, fld.count)
+	for j := 0; j < fld.count; j++ {
+		if lineLevel(lineRec(fld, j)) >= fld.minLevel {
+			list = append(list, j)
+		}
+	}
+	return
+}
+
+// lineRows returns the number of rows occupied by a rolling line field with
+// height ht that has count entries passing its filter.
+func lineRows(ht, count int) int {
+	if count < ht {
+		return count
+	}
+	return ht
+}
+
+// lineScroll moves the view of the rolling line field specified by fld back
+// in history by delta shown entries, or forward if delta is negative. A
+// scroll position of zero follows new entries as they arrive.
+func lineScroll(fld fieldPtrType, delta int) {
+	count := len(lineShown(fld))
+	fld.scroll += delta
+	max := count - lineRows(fld.ht, count)
+	if fld.scroll > max {
+		fld.scroll = max
+	}
+	if fld.scroll < 0 {
+		fld.scroll = 0
+	}
+}
+
+// lineStrPut writes str starting at x, truncating it with ".." if it extends
+// past lim and padding it with blanks otherwise. Occurrences of matchStr, if
+// it is not empty, are shown in reverse video.
+func (dsh *Dashboard) lineStrPut(valSt tcell.Style, x, y, lim int, str, matchStr string) {
+	var tailStr string
+	length := util.StrWidth(str)
+	if length+x <= lim {
+		gap := lim - length - x
+		if gap > cnMaxWidth {
+			gap = cnMaxWidth
+		}
+		tailStr = blankStr[:gap]
+	} else if lim-x >= 2 {
+		str = util.StrTruncate(str, lim-x-2, "")
+		tailStr = ".."
+		if gap := lim - x - 2 - util.StrWidth(str); gap > 0 {
+			// wide character did not fit
+			tailStr = blankStr[:gap] + tailStr
+		}
+	} else {
+		str = ""
+	}
+	if matchStr != "" {
+		for pos := strings.Index(str, matchStr); pos >= 0; pos = strings.Index(str, matchStr) {
+			x = dsh.put(valSt, x, y, lim, str[:pos])
+			x = dsh.put(valSt.Reverse(true), x, y, lim, matchStr)
+			str = str[pos+len(matchStr):]
+		}
+	}
+	dsh.put(valSt, x, y, lim, str, tailStr)
+}
+
+// levelStyle returns the style used for the tag of entries with the
+// specified severity level.
+func (thm *ThemeType) levelStyle(level int) tcell.Style {
+	switch level {
+	case LevelDebug:
+		return thm.Dim
+	case LevelWarn:
+		return thm.Warn
+	case LevelError:
+		return thm.Error
+	}
+	return thm.OK
+}
+
+// linePut draws the visible entries of the rolling line field specified by
+// fld. The timestamp, level tag, source and message of each entry are drawn
+// in their own styles. A field with keyboard focus shows a scroll bar in its
+// rightmost column, highlights matches of the current search pattern and,
+// while a pattern is being entered, shows it on the bottom row.
+func (dsh *Dashboard) linePut(scrWd int, fld fieldPtrType) {
+	var matchStr string
+	thm := &dsh.theme
+	shown := lineShown(fld)
+	count := len(shown)
+	rows := lineRows(fld.ht, count)
+	focused := dsh.focusOK && dsh.focusID == fld.id
+	lim := fld.x + fieldWidth(fld, scrWd)
+	if lim > scrWd {
+		lim = scrWd
+	}
+	if focused {
+		lim--
+		matchStr = dsh.searchStr
+	}
+	first := count - rows - fld.scroll
+	if first < 0 {
+		first = 0
+	}
+	for j := 0; j < rows; j++ {
+		rec := lineRec(fld, shown[first+j])
+		x := fld.x
+		y := fld.y + j
+		if fld.timeFmtStr != "" {
+			x = dsh.put(thm.Key, x, y, lim, rec.tm.Format(fld.timeFmtStr))
+		}
+		if rec.level > LevelNone && rec.level <= LevelError {
+			x = dsh.put(thm.levelStyle(rec.level), x, y, lim, levelTags[rec.level])
+		}
+		if rec.srcStr != "" {
+			x = dsh.put(thm.Key, x, y, lim, rec.srcStr, ": ")
+		}
+		dsh.lineStrPut(thm.Value, x, y, lim, rec.msgStr, matchStr)
+	}
+	// Clear rows vacated when the filter hides entries
+	for j := rows; j < fld.ht && j < lineRows(fld.ht, fld.count); j++ {
+		dsh.lineStrPut(thm.Value, fld.x, fld.y+j, lim, "", "")
+	}
+	if focused {
+		dsh.scrollBarPut(thm.Key, lim, fld.y, fld.ht, first, count, rows)
+		if dsh.searchEdit {
+			y := fld.y
+			if rows > 0 {
+				y += rows - 1
+			}
+			x := dsh.put(thm.Key, fld.x, y, lim, "/")
+			dsh.lineStrPut(thm.Value, x, y, lim, string(dsh.searchBuf), "")
+		}
+	}
+}
+
+// scrollBarPut draws a vertical scroll bar of height ht at column x starting
+// at row y. The view shows rows entries of total, beginning with entry first.
+func (dsh *Dashboard) scrollBarPut(st tcell.Style, x, y, ht, first, total, rows int) {
+	thumb := rows - 1
+	if total > rows {
+		thumb = (rows - 1) * first / (total - rows)
+	}
+	for j := 0; j < ht; j++ {
+		rn := tcell.RuneVLine
+		if j == thumb {
+			rn = tcell.RuneBlock
+		}
+		dsh.screen.SetContent(x, y+j, rn, nil, st)
+	}
+}
+
+// focusRender redraws all fields that can receive keyboard focus.
+func (dsh *Dashboard) focusRender() {
+	scrWd, _ := dsh.screen.Size()
+	list := dsh.focusList()
+	for _, fieldPtr := range list {
+		dsh.fieldPut(scrWd, fieldPtr)
+	}
+	if len(list) > 0 {
+		dsh.screen.Show()
+	}
+}
+
+// focusList returns the fields that can receive keyboard focus, rolling line
+// and table fields, in order of identifier.
+func (dsh *Dashboard) focusList() (list []fieldPtrType) {
+	dsh.fieldMtx.Lock()
+	for _, fieldPtr := range dsh.fieldMap {
+		if (fieldPtr.item == itemLine || fieldPtr.item == itemTable) && !fieldPtr.hidden {
+			list = append(list, fieldPtr)
+		}
+	}
+	dsh.fieldMtx.Unlock()
+	sort.Slice(list, func(a, b int) bool {
+		return list[a].id < list[b].id
+	})
+	return
+}
+
+// focusNext moves the keyboard focus to the rolling line or table field that
+// follows the currently focused one. After the last field, no field has
+// focus. Any search is cleared.
+func (dsh *Dashboard) focusNext() {
+	list := dsh.focusList()
+	next := 0
+	for j, fieldPtr := range list {
+		if dsh.focusOK && fieldPtr.id == dsh.focusID {
+			next = j + 1
+		}
+	}
+	dsh.searchEdit = false
+	dsh.searchStr = ""
+	dsh.focusOK = next < len(list)
+	if dsh.focusOK {
+		dsh.focusID = list[next].id
+	}
+}
+
+// lineSearch scrolls the rolling line field specified by fld so that the
+// nearest shown entry whose message contains matchStr is on its bottom row.
+// The positions of the shown entries are specified by shown. The search
+// begins with shown entry from (zero is the oldest) and proceeds toward older
+// entries if older is true, otherwise toward newer entries. The view is
+// unchanged if no match is found.
+func lineSearch(fld fieldPtrType, shown []int, matchStr string, from int, older bool) {
+	count := len(shown)
+	bottom := count - 1 - fld.scroll
+	step := 1
+	if older {
+		step = -1
+	}
+	for j := from; j >= 0 && j < count; j += step {
+		if strings.Contains(lineRec(fld, shown[j]).msgStr, matchStr) {
+			lineScroll(fld, bottom-j)
+			return
+		}
+	}
+}
+
+// lineLevelNext advances the minimum level of entries shown by the rolling
+// line field specified by fld, wrapping from LevelError back to LevelDebug.
+// The view returns to the newest entries.
+func lineLevelNext(fld fieldPtrType) {
+	fld.minLevel++
+	if fld.minLevel <= LevelDebug || fld.minLevel > LevelError {
+		fld.minLevel = LevelDebug
+	}
+	fld.scroll = 0
+}
+
+// lineKey responds to the key event specified by ev if it applies to rolling
+// line or table fields. Tab moves the keyboard focus between fields, and keys
+// for a focused table are passed to tableKey(). In the focused
+// field, the arrow, page, Home and End keys scroll through its history, and
+// '/' begins entering a search pattern. After a pattern is entered, 'n' and
+// 'N' find older and newer matches, and Escape clears it. 'l' raises the
+// minimum severity level of the entries shown, cycling from LevelDebug to
+// LevelError. Scrolling back pauses the field; End resumes following new
+// entries. handled is false if ev does not apply.
+func (dsh *Dashboard) lineKey(ev *tcell.EventKey) (handled bool) {
+	var fld fieldPtrType
+	if ev.Key() == tcell.KeyTab {
+		dsh.focusNext()
+		dsh.focusRender()
+		return true
+	}
+	if !dsh.focusOK {
+		return false
+	}
+	dsh.fieldMtx.Lock()
+	fld = dsh.fieldMap[dsh.focusID]
+	dsh.fieldMtx.Unlock()
+	if fld.item == itemTable {
+		return dsh.tableKey(fld, ev)
+	}
+	shown := lineShown(fld)
+	count := len(shown)
+	handled = true
+	if dsh.searchEdit {
+		switch ev.Key() {
+		case tcell.KeyRune:
+			dsh.searchBuf = append(dsh.searchBuf, ev.Rune())
+		case tcell.KeyBackspace, tcell.KeyBackspace2:
+			if len(dsh.searchBuf) > 0 {
+				dsh.searchBuf = dsh.searchBuf[:len(dsh.searchBuf)-1]
+			}
+		case tcell.KeyEnter:
+			dsh.searchEdit = false
+			dsh.searchStr = string(dsh.searchBuf)
+			if dsh.searchStr != "" {
+				lineSearch(fld, shown, dsh.searchStr, count-1-fld.scroll, true)
+			}
+		case tcell.KeyEscape:
+			dsh.searchEdit = false
+		}
+	} else {
+		switch ev.Key() {
+		case tcell.KeyUp:
+			lineScroll(fld, 1)
+		case tcell.KeyDown:
+			lineScroll(fld, -1)
+		case tcell.KeyPgUp:
+			lineScroll(fld, fld.ht)
+		case tcell.KeyPgDn:
+			lineScroll(fld, -fld.ht)
+		case tcell.KeyHome:
+			lineScroll(fld, count)
+		case tcell.KeyEnd:
+			fld.scroll = 0
+		case tcell.KeyEscape:
+			handled = dsh.searchStr != ""
+			dsh.searchStr = ""
+		case tcell.KeyRune:
+			switch ev.Rune() {
+			case '/':
+				dsh.searchEdit = true
+				dsh.searchBuf = dsh.searchBuf[:0]
+			case 'l':
+				lineLevelNext(fld)
+			case 'n', 'N':
+				if dsh.searchStr != "" {
+					older := ev.Rune() == 'n'
+					from := count - fld.scroll
+					if older {
+						from -= 2
+					}
+					lineSearch(fld, shown, dsh.searchStr, from, older)
+				} else {
+					handled = false
+				}
+			default:
+				handled = false
+			}
+		default:
+			handled = false
+		}
+	}
+	if handled {
+		scrWd, _ := dsh.screen.Size()
+		dsh.linePut(scrWd, fld)
+		dsh.screen.Show()
+	}
+	return
+}
+
+// RegisterLineHistory registers a dashboard rolling line field with the
+// identifier specified by id. Its coordinates are specified by x and y, and
+// the total number of rows used is specified by lineCount. Up to historyCount
+// entries are retained; the user can focus the field with Tab and scroll back
+// through them or search them. If timeFmt is empty, no timestamp prefix will
+// be displayed. Otherwise, it will be used to format a leading timestamp.
+func (dsh *Dashboard) RegisterLineHistory(id, x, y, lineCount, historyCount int, timeFmtStr string) {
+	var fld fieldType
+
+	if historyCount < lineCount {
+		historyCount = lineCount
+	}
+	fld.recList = make([]lineRecType, historyCount)
+	fld.item = itemLine
+	fld.id = id
+	fld.x = x
+	fld.y = y
+	fld.ht = lineCount
+	fld.timeFmtStr = timeFmtStr
+	dsh.fieldRegister(id, &fld)
+}
+
+// RegisterLine registers a dashboard rolling line field with the identifier
+// specified by id. Its coordinates are specified by x and y, and the total
+// number of rows used is specified by lineCount. If timeFmt is empty, no
+// timestamp prefix will be displayed. Otherwise, it will be used to format a
+// leading timestamp.
+func (dsh *Dashboard) RegisterLine(id, x, y, lineCount int, timeFmtStr string) {
+	dsh.RegisterLineHistory(id, x, y, lineCount, lineCount, timeFmtStr)
+}
+
+// UpdateLine updates the rolling line field specified by id with the str.
+// The entry has no level tag.
+func (dsh *Dashboard) UpdateLine(id int, str string) {
+	dsh.send(updateType{id: id, str: str})
+}
+
+// UpdateLineLevel adds the message specified by str to the rolling line field
+// specified by id. The entry is tagged with the severity level specified by
+// level, one of LevelDebug, LevelInfo, LevelWarn or LevelError, and
+// attributed to the source specified by srcStr unless it is empty.
+func (dsh *Dashboard) UpdateLineLevel(id, level int, srcStr, str string) {
+	dsh.send(updateType{id: id, str: str, level: level, src: srcStr})
+}
+
+// LineWriterType directs text to a rolling line field. It implements
+// io.Writer, so it can be used as the output of a log.Logger, and
+// util.Printer.
+type LineWriterType struct {
+	dsh    *Dashboard
+	id     int
+	level  int
+	srcStr string
+}
+
+// LineWriter returns a writer that adds each line of text written to it to
+// the rolling line field specified by id. The entries are tagged with level
+// and srcStr as with UpdateLineLevel(). For example,
+//
+//	lg := log.New(dsh.LineWriter(cnLog, dashboard.LevelWarn, "net"), "", 0)
+//
+// routes the output of lg to the field. Since the field can timestamp its
+// entries, the logger is typically created without flags.
+func (dsh *Dashboard) LineWriter(id, level int, srcStr string) *LineWriterType {
+	return &LineWriterType{dsh: dsh, id: id, level: level, srcStr: srcStr}
+}
+
+// Write implements the io.Writer interface. Each line of buf becomes an
+// entry; a trailing newline does not produce an empty one.
+func (lw *LineWriterType) Write(buf []byte) (n int, err error) {
+	str := strings.TrimSuffix(string(buf), "\n")
+	if str != "" {
+		for _, lineStr := range strings.Split(str, "\n") {
+			lw.dsh.UpdateLineLevel(lw.id, lw.level, lw.srcStr, lineStr)
+		}
+	}
+	n = len(buf)
+	return
+}
+
+// Print implements the util.Printer interface. The arguments are formatted
+// as with fmt.Sprint().
+func (lw *LineWriterType) Print(v ...interface{}) {
+	lw.Write([]byte(fmt.Sprint(v...)))
+}
+
+// RegisterLine registers a rolling line field with the default dashboard. See
+// Dashboard.RegisterLine() for details.
+func RegisterLine(id, x, y, lineCount int, timeFmtStr string) {
+	std.RegisterLine(id, x, y, lineCount, timeFmtStr)
+}
+
+// RegisterLineHistory registers a rolling line field with scrollback with the
+// default dashboard. See Dashboard.RegisterLineHistory() for details.
+func RegisterLineHistory(id, x, y, lineCount, historyCount int, timeFmtStr string) {
+	std.RegisterLineHistory(id, x, y, lineCount, historyCount, timeFmtStr)
+}
+
+// UpdateLine updates a rolling line field of the default dashboard.
+func UpdateLine(id int, str string) {
+	std.UpdateLine(id, str)
+}
+
+// UpdateLineLevel adds an entry with a severity level to a rolling line field
+// of the default dashboard. See Dashboard.UpdateLineLevel() for details.
+func UpdateLineLevel(id, level int, srcStr, str string) {
+	std.UpdateLineLevel(id, level, srcStr, str)
+}
+
+// LineWriter returns a writer that directs text to a rolling line field of
+// the default dashboard. See Dashboard.LineWriter() for details.
+func LineWriter(id, level int, srcStr string) *LineWriterType {
+	return std.LineWriter(id, level, srcStr)
+}
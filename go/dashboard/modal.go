@@ -0,0 +1,302 @@
+package dashboard
+
+import (
+	"github.com/gdamore/tcell"
+	"github.com/jung-kurt/etc/go/util"
+)
+
+// InputResultType is sent to the application when a prompt or menu is
+// closed. OK is false if the user cancelled with Escape or the dashboard
+// stopped before a choice was made. Str holds the entered text or the
+// selected menu item, and Index holds the position of the selected menu item
+// (-1 for a prompt).
+type InputResultType struct {
+	OK    bool
+	Str   string
+	Index int
+}
+
+// modalType holds the state of a prompt or menu shown over the fields.
+type modalType struct {
+	menu     bool                   // menu rather than prompt
+	titleStr string                 // shown in top edge of box
+	buf      []rune                 // text being edited
+	cur      int                    // cursor position in buf, or selected menu item
+	top      int                    // first menu item shown
+	itemList []string               // menu items
+	validate func(str string) error // prompt validation, nil for none
+	errStr   string                 // most recent validation error
+	resChan  chan InputResultType   // receives result when closed
+}
+
+// modalClose sends the result of the active prompt or menu to the
+// application and removes it. The next queued prompt or menu, if any, becomes
+// active.
+func (dsh *Dashboard) modalClose(res InputResultType) {
+	md := dsh.modalList[0]
+	md.resChan <- res
+	dsh.modalList = dsh.modalList[1:]
+}
+
+// modalCancel closes all queued prompts and menus without a result.
+func (dsh *Dashboard) modalCancel() {
+	for len(dsh.modalList) > 0 {
+		dsh.modalClose(InputResultType{Index: -1})
+	}
+}
+
+// promptKey responds to the key event specified by ev on behalf of the prompt
+// specified by md. Left, Right, Home, End, Backspace and Delete edit the text
+// in the usual way, and Ctrl-U clears it. Enter submits the text if it passes
+// validation; Escape cancels the prompt.
+func (dsh *Dashboard) promptKey(md *modalType, ev *tcell.EventKey) {
+	switch ev.Key() {
+	case tcell.KeyRune:
+		md.buf = append(md.buf, 0)
+		copy(md.buf[md.cur+1:], md.buf[md.cur:])
+		md.buf[md.cur] = ev.Rune()
+		md.cur++
+	case tcell.KeyLeft:
+		if md.cur > 0 {
+			md.cur--
+		}
+	case tcell.KeyRight:
+		if md.cur < len(md.buf) {
+			md.cur++
+		}
+	case tcell.KeyHome, tcell.KeyCtrlA:
+		md.cur = 0
+	case tcell.KeyEnd, tcell.KeyCtrlE:
+		md.cur = len(md.buf)
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if md.cur > 0 {
+			md.buf = append(md.buf[:md.cur-1], md.buf[md.cur:]...)
+			md.cur--
+		}
+	case tcell.KeyDelete:
+		if md.cur < len(md.buf) {
+			md.buf = append(md.buf[:md.cur], md.buf[md.cur+1:]...)
+		}
+	case tcell.KeyCtrlU:
+		md.buf = md.buf[:0]
+		md.cur = 0
+	case tcell.KeyEnter:
+		var err error
+		str := string(md.buf)
+		if md.validate != nil {
+			err = md.validate(str)
+		}
+		if err == nil {
+			dsh.modalClose(InputResultType{OK: true, Str: str, Index: -1})
+		} else {
+			md.errStr = err.Error()
+		}
+	case tcell.KeyEscape:
+		dsh.modalClose(InputResultType{Index: -1})
+	}
+}
+
+// menuKey responds to the key event specified by ev on behalf of the menu
+// specified by md. The arrow, page, Home and End keys move the selection,
+// Enter chooses the selected item and Escape cancels the menu.
+func (dsh *Dashboard) menuKey(md *modalType, ev *tcell.EventKey) {
+	_, scrHt := dsh.screen.Size()
+	page := scrHt - 2
+	if page < 1 {
+		page = 1
+	}
+	last := len(md.itemList) - 1
+	switch ev.Key() {
+	case tcell.KeyUp:
+		md.cur--
+	case tcell.KeyDown:
+		md.cur++
+	case tcell.KeyPgUp:
+		md.cur -= page
+	case tcell.KeyPgDn:
+		md.cur += page
+	case tcell.KeyHome:
+		md.cur = 0
+	case tcell.KeyEnd:
+		md.cur = last
+	case tcell.KeyEnter:
+		if md.cur >= 0 && md.cur <= last {
+			dsh.modalClose(InputResultType{OK: true, Str: md.itemList[md.cur], Index: md.cur})
+		}
+	case tcell.KeyEscape:
+		dsh.modalClose(InputResultType{Index: -1})
+	}
+	if md.cur > last {
+		md.cur = last
+	}
+	if md.cur < 0 {
+		md.cur = 0
+	}
+}
+
+// modalKey passes the key event specified by ev to the active prompt or menu
+// and redraws the screen. handled is false if neither is shown.
+func (dsh *Dashboard) modalKey(ev *tcell.EventKey) (handled bool) {
+	if len(dsh.modalList) > 0 {
+		md := dsh.modalList[0]
+		if md.menu {
+			dsh.menuKey(md, ev)
+		} else {
+			dsh.promptKey(md, ev)
+		}
+		dsh.render()
+		dsh.screen.Show()
+		handled = true
+	}
+	return
+}
+
+// promptPut draws the prompt specified by md in a box centered on the screen.
+// The text is scrolled horizontally to keep the cursor, shown in reverse
+// video, in view. A validation error is shown below the text.
+func (dsh *Dashboard) promptPut(md *modalType, scrWd, scrHt int) {
+	thm := &dsh.theme
+	wd := util.StrWidth(md.titleStr) + 6
+	if wd < 40 {
+		wd = 40
+	}
+	if wd > scrWd {
+		wd = scrWd
+	}
+	if wd > cnMaxWidth {
+		wd = cnMaxWidth
+	}
+	ht := 4
+	if wd < 6 || ht > scrHt {
+		return
+	}
+	x := (scrWd - wd) / 2
+	y := (scrHt - ht) / 2
+	textLim := x + wd - 2
+	boxLim := x + wd - 1
+	dsh.boxPut(thm.Key, thm.Banner, x, y, wd, ht, scrWd, md.titleStr)
+	start := 0
+	for util.StrWidth(string(md.buf[start:md.cur])) >= wd-4 {
+		start++
+	}
+	curStr := " "
+	tailStr := ""
+	if md.cur < len(md.buf) {
+		curStr = string(md.buf[md.cur])
+		tailStr = string(md.buf[md.cur+1:])
+	}
+	left := dsh.put(thm.Value, x+1, y+1, boxLim, " ")
+	left = dsh.put(thm.Value, left, y+1, textLim, string(md.buf[start:md.cur]))
+	left = dsh.put(thm.Value.Reverse(true), left, y+1, textLim, curStr)
+	left = dsh.put(thm.Value, left, y+1, textLim, tailStr)
+	dsh.put(thm.Value, left, y+1, boxLim, blankStr[:wd])
+	left = dsh.put(thm.Value, x+1, y+2, boxLim, " ")
+	left = dsh.put(thm.Error, left, y+2, textLim, md.errStr)
+	dsh.put(thm.Value, left, y+2, boxLim, blankStr[:wd])
+}
+
+// menuPut draws the menu specified by md in a box centered on the screen. If
+// there are more items than fit, the list scrolls to keep the selected item,
+// shown in reverse video, in view.
+func (dsh *Dashboard) menuPut(md *modalType, scrWd, scrHt int) {
+	thm := &dsh.theme
+	wd := util.StrWidth(md.titleStr) + 6
+	for _, str := range md.itemList {
+		if itemWd := util.StrWidth(str) + 4; itemWd > wd {
+			wd = itemWd
+		}
+	}
+	if wd > scrWd {
+		wd = scrWd
+	}
+	if wd > cnMaxWidth {
+		wd = cnMaxWidth
+	}
+	rows := len(md.itemList)
+	if rows > scrHt-2 {
+		rows = scrHt - 2
+	}
+	if wd < 4 || rows < 1 {
+		return
+	}
+	if md.cur < md.top {
+		md.top = md.cur
+	} else if md.cur >= md.top+rows {
+		md.top = md.cur - rows + 1
+	}
+	x := (scrWd - wd) / 2
+	y := (scrHt - rows - 2) / 2
+	lim := x + wd - 1
+	dsh.boxPut(thm.Key, thm.Banner, x, y, wd, rows+2, scrWd, md.titleStr)
+	for j := 0; j < rows; j++ {
+		k := md.top + j
+		st := thm.Value
+		if k == md.cur {
+			st = st.Reverse(true)
+		}
+		str := util.StrPad(util.StrTruncate(md.itemList[k], wd-4, ".."), wd-4)
+		left := dsh.put(thm.Value, x+1, y+1+j, lim, " ")
+		left = dsh.put(st, left, y+1+j, lim, str)
+		dsh.put(thm.Value, left, y+1+j, lim, " ")
+	}
+}
+
+// modalPut draws the active prompt or menu, if any.
+func (dsh *Dashboard) modalPut(scrWd, scrHt int) {
+	if len(dsh.modalList) > 0 {
+		md := dsh.modalList[0]
+		if md.menu {
+			dsh.menuPut(md, scrWd, scrHt)
+		} else {
+			dsh.promptPut(md, scrWd, scrHt)
+		}
+	}
+}
+
+// Prompt shows a box with the title specified by titleStr in which the user
+// can enter a line of text. The text initially holds initStr. The returned
+// channel receives the result once the user presses Enter or Escape. If
+// validate is not nil, it is called with the text when Enter is pressed; if
+// it returns an error, the error is shown and the prompt remains open. The
+// validate function is called by the dashboard goroutine, so it should return
+// promptly and must not update the dashboard. While a prompt or menu is
+// shown, it receives all keys; additional prompts and menus are queued. A
+// headless dashboard cancels prompts immediately.
+func (dsh *Dashboard) Prompt(titleStr, initStr string, validate func(str string) error) <-chan InputResultType {
+	md := &modalType{titleStr: titleStr, buf: []rune(initStr), validate: validate}
+	md.cur = len(md.buf)
+	md.resChan = make(chan InputResultType, 1)
+	dsh.control(updateType{id: updateModal, modal: md})
+	return md.resChan
+}
+
+// Menu shows a box with the title specified by titleStr that lists the items
+// specified by itemList, with item sel initially selected. The user moves the
+// selection with the arrow keys. The returned channel receives the result
+// once the user presses Enter or Escape. If itemList is empty, the result is
+// sent immediately with OK set to false. See Prompt() for more details.
+func (dsh *Dashboard) Menu(titleStr string, itemList []string, sel int) <-chan InputResultType {
+	if sel < 0 || sel >= len(itemList) {
+		sel = 0
+	}
+	md := &modalType{menu: true, titleStr: titleStr, itemList: itemList, cur: sel}
+	md.resChan = make(chan InputResultType, 1)
+	if len(itemList) == 0 {
+		md.resChan <- InputResultType{Index: -1}
+	} else {
+		dsh.control(updateType{id: updateModal, modal: md})
+	}
+	return md.resChan
+}
+
+// Prompt shows a text entry box over the default dashboard. See
+// Dashboard.Prompt() for details.
+func Prompt(titleStr, initStr string, validate func(str string) error) <-chan InputResultType {
+	return std.Prompt(titleStr, initStr, validate)
+}
+
+// Menu shows a selection box over the default dashboard. See Dashboard.Menu()
+// for details.
+func Menu(titleStr string, itemList []string, sel int) <-chan InputResultType {
+	return std.Menu(titleStr, itemList, sel)
+}
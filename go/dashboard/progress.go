@@ -0,0 +1,177 @@
+package dashboard
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/gdamore/tcell"
+	"github.com/jung-kurt/etc/go/util"
+)
+
+// ThresholdType associates a gauge value with the colour used to display the
+// gauge when its value is at or above Val.
+type ThresholdType struct {
+	Val   float64
+	Color tcell.Color
+}
+
+// barPut draws a horizontal bar for the field specified by fld. The static key
+// is written first, followed by a bar filled to the fraction specified by frac
+// and then by sufStr. The bar width is adjusted so that the entire field width
+// is covered. If the field is too narrow to hold a meaningful bar, the
+// overflow indicator is shown instead.
+func (dsh *Dashboard) barPut(keySt, fillSt, emptySt, sufSt tcell.Style, scrWd int,
+	fld fieldPtrType, frac float64, sufStr string) {
+	wd := fieldWidth(fld, scrWd)
+	if wd > cnMaxWidth {
+		wd = cnMaxWidth
+	}
+	keyLen := util.StrWidth(fld.str)
+	barWd := wd - util.StrWidth(sufStr)
+	if keyLen > 0 {
+		barWd -= keyLen + 1
+	}
+	if barWd >= 4 {
+		fillWd := int(frac * float64(barWd))
+		x := fld.x
+		if keyLen > 0 {
+			x = dsh.put(keySt, x, fld.y, scrWd, fld.str, " ")
+		}
+		x = dsh.put(fillSt, x, fld.y, scrWd, string(blocks[:fillWd]))
+		x = dsh.put(emptySt, x, fld.y, scrWd, string(boards[:barWd-fillWd]))
+		dsh.put(sufSt, x, fld.y, scrWd, sufStr)
+	} else if wd > 0 {
+		dsh.put(keySt, fld.x, fld.y, scrWd, string(diamonds[:wd]))
+	}
+}
+
+// clamp returns val limited to the range specified by lo and hi. A value that
+// is not a number is treated as lo.
+func clamp(val, lo, hi float64) float64 {
+	if val < lo || math.IsNaN(val) {
+		return lo
+	}
+	if val > hi {
+		return hi
+	}
+	return val
+}
+
+// progressUpdate records the fraction specified by frac in the progress field
+// specified by fld. The starting time and fraction used to estimate the time
+// of completion are reset if the fraction decreases.
+func progressUpdate(fld fieldPtrType, frac float64) {
+	frac = clamp(frac, 0, 1)
+	if fld.startTm.IsZero() || frac < fld.val {
+		fld.startTm = time.Now()
+		fld.startVal = frac
+	}
+	fld.val = frac
+}
+
+// progressETA returns the estimated time remaining for the progress field
+// specified by fld based on its rate of progress so far. Zero is returned if
+// no estimate can be made.
+func progressETA(fld fieldPtrType) (eta time.Duration) {
+	done := fld.val - fld.startVal
+	if done > 0 && fld.val < 1 {
+		elapsed := time.Since(fld.startTm)
+		eta = time.Duration(float64(elapsed) * (1 - fld.val) / done).Round(time.Second)
+	}
+	return
+}
+
+func (dsh *Dashboard) progressPut(keySt, valSt, barSt tcell.Style, scrWd int, fld fieldPtrType) {
+	dsh.buf.Reset()
+	fmt.Fprintf(dsh.buf, " %3d%%", int(fld.val*100))
+	if fld.valStr != "" {
+		dsh.buf.WriteString(" ")
+		dsh.buf.WriteString(fld.valStr)
+	}
+	eta := progressETA(fld)
+	if eta > 0 {
+		fmt.Fprintf(dsh.buf, " ETA %s", eta)
+	}
+	dsh.barPut(keySt, barSt, keySt, valSt, scrWd, fld, fld.val, dsh.buf.String())
+}
+
+func (dsh *Dashboard) gaugePut(keySt, valSt tcell.Style, scrWd int, fld fieldPtrType) {
+	st := valSt
+	for _, th := range fld.thresholds {
+		if fld.val >= th.Val {
+			st = valSt.Foreground(th.Color)
+		}
+	}
+	var frac float64
+	if fld.max > fld.min {
+		frac = clamp((fld.val-fld.min)/(fld.max-fld.min), 0, 1)
+	}
+	sufStr := " " + util.Float64ToStrSig(fld.val, ".", ",", 3, 3)
+	dsh.barPut(keySt, st, keySt, st, scrWd, fld, frac, sufStr)
+}
+
+// RegisterProgress registers a dashboard progress bar with the identifier
+// specified by id. Its coordinates are specified by x and y. The total field's
+// width is specified by wd; a zero value indicates the full width of the
+// screen, and a negative value indicates the position from the right. The
+// static key shown to the left of the bar is specified by keyStr.
+func (dsh *Dashboard) RegisterProgress(id, x, y, wd int, keyStr string) {
+	dsh.fieldRegister(id, &fieldType{id: id, item: itemProgress, x: x, y: y, wd: wd, str: keyStr})
+}
+
+// UpdateProgress updates the progress bar specified by id with the completed
+// fraction specified by frac, a value from 0 to 1. If label is not empty, it
+// is shown after the percentage. An estimated time to completion, based on
+// the rate of progress since the first update, is shown as well. A decrease
+// in frac restarts the estimate.
+func (dsh *Dashboard) UpdateProgress(id int, frac float64, label string) {
+	dsh.send(updateType{id: id, val: frac, str: label})
+}
+
+// RegisterGauge registers a dashboard horizontal gauge with the identifier
+// specified by id. Its coordinates are specified by x and y. The total field's
+// width is specified by wd; a zero value indicates the full width of the
+// screen, and a negative value indicates the position from the right. The
+// static key shown to the left of the gauge is specified by keyStr. The values
+// that correspond to an empty and full gauge are specified by min and max.
+// The gauge and its value are shown in the colour of the highest threshold
+// that the value reaches, or in the default value colour if no threshold is
+// reached.
+func (dsh *Dashboard) RegisterGauge(id, x, y, wd int, keyStr string, min, max float64, thresholds ...ThresholdType) {
+	fld := fieldType{id: id, item: itemGauge, x: x, y: y, wd: wd, str: keyStr, min: min, max: max}
+	fld.thresholds = append([]ThresholdType(nil), thresholds...)
+	sort.Slice(fld.thresholds, func(a, b int) bool {
+		return fld.thresholds[a].Val < fld.thresholds[b].Val
+	})
+	dsh.fieldRegister(id, &fld)
+}
+
+// UpdateGauge updates the gauge specified by id with the value specified by
+// val.
+func (dsh *Dashboard) UpdateGauge(id int, val float64) {
+	dsh.send(updateType{id: id, val: val})
+}
+
+// RegisterProgress registers a progress bar with the default dashboard. See
+// Dashboard.RegisterProgress() for details.
+func RegisterProgress(id, x, y, wd int, keyStr string) {
+	std.RegisterProgress(id, x, y, wd, keyStr)
+}
+
+// UpdateProgress updates a progress bar of the default dashboard.
+func UpdateProgress(id int, frac float64, label string) {
+	std.UpdateProgress(id, frac, label)
+}
+
+// RegisterGauge registers a horizontal gauge with the default dashboard. See
+// Dashboard.RegisterGauge() for details.
+func RegisterGauge(id, x, y, wd int, keyStr string, min, max float64, thresholds ...ThresholdType) {
+	std.RegisterGauge(id, x, y, wd, keyStr, min, max, thresholds...)
+}
+
+// UpdateGauge updates a gauge of the default dashboard.
+func UpdateGauge(id int, val float64) {
+	std.UpdateGauge(id, val)
+}
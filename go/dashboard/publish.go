@@ -0,0 +1,39 @@
+package dashboard
+
+// Publisher is implemented by types that distribute events by category. The
+// longpoll.Manager type in github.com/jung-kurt/etc/go/longpoll satisfies
+// this interface, allowing a dashboard to be watched from a browser with
+// js/longpoll.js.
+type Publisher interface {
+	Publish(category string, data interface{})
+}
+
+// FieldEventType is published for every field update. Value holds the
+// field's value as text; for rolling line fields it is the new entry.
+type FieldEventType struct {
+	ID    int    `json:"id"`
+	Key   string `json:"key,omitempty"`
+	Value string `json:"value"`
+}
+
+// publish sends the current value of the field specified by fld to the
+// dashboard's publisher, if one has been assigned.
+func (dsh *Dashboard) publish(fld fieldPtrType) {
+	if dsh.pub != nil {
+		dsh.pub.Publish(dsh.pubCategory, FieldEventType{ID: fld.id, Key: fld.str, Value: fieldText(fld)})
+	}
+}
+
+// SetPublisher arranges for every field update to be published to pub, in
+// the category specified by category, as a FieldEventType value. This method
+// must be called before Run().
+func (dsh *Dashboard) SetPublisher(pub Publisher, category string) {
+	dsh.pub = pub
+	dsh.pubCategory = category
+}
+
+// SetPublisher arranges for field updates of the default dashboard to be
+// published. See Dashboard.SetPublisher() for details.
+func SetPublisher(pub Publisher, category string) {
+	std.SetPublisher(pub, category)
+}
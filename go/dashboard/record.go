@@ -0,0 +1,132 @@
+package dashboard
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// ReplayStep is the speed passed to Replay() to advance one update at a time
+// with Step().
+const ReplayStep = 0
+
+// RecordType is a field update as written by a recorder, one JSON object per
+// line. Time is in milliseconds since the Unix epoch. The other members
+// correspond to the arguments of the UpdateXXX() method that produced the
+// update; Cells holds the formatted cells of a table row.
+type RecordType struct {
+	Time  int64    `json:"t"`
+	ID    int      `json:"id"`
+	Str   string   `json:"s,omitempty"`
+	Val   float64  `json:"v,omitempty"`
+	OK    bool     `json:"ok,omitempty"`
+	Style int      `json:"st,omitempty"`
+	Level int      `json:"lv,omitempty"`
+	Src   string   `json:"src,omitempty"`
+	Cells []string `json:"c,omitempty"`
+	Del   bool     `json:"del,omitempty"`
+}
+
+// record writes the update specified by up, which has just been applied to
+// the field specified by fld, to the recorder. Recording stops after the
+// first write error.
+func (dsh *Dashboard) record(fld fieldPtrType, up updateType) {
+	if dsh.rec != nil && dsh.recErr == nil {
+		rec := RecordType{Time: time.Now().UnixNano() / int64(time.Millisecond), ID: up.id,
+			Str: up.str, Val: up.val, OK: up.ok, Style: up.style, Level: up.level, Src: up.src,
+			Del: up.del}
+		if fld.item == itemTable && !up.del {
+			rec.Cells = tableCells(fld, up.str)
+		}
+		dsh.recErr = dsh.rec.Encode(&rec)
+	}
+}
+
+// SetRecorder arranges for every field update to be written to w with a
+// timestamp, as a line of JSON holding a RecordType value, so that the
+// session can later be played back with Replay(). If a write fails,
+// recording stops and Run() returns the error when it finishes. This method
+// must be called before Run().
+func (dsh *Dashboard) SetRecorder(w io.Writer) {
+	dsh.rec = json.NewEncoder(w)
+	dsh.recErr = nil
+}
+
+// Replay reads a recording made with SetRecorder() from r and applies its
+// updates to the dashboard, which should have the same fields registered as
+// the one that was recorded. If speed is positive, the time between updates
+// is the recorded interval divided by speed, so 1 replays at the original
+// pace and larger values replay faster. If speed is ReplayStep, each update
+// waits for a call to Step(); the application might bind Step() to a key.
+// Replay returns nil once the recording has been played, or an error if it
+// cannot be read or ctx is done. It is typically run in its own goroutine
+// while Run() is active.
+func (dsh *Dashboard) Replay(ctx context.Context, r io.Reader, speed float64) (err error) {
+	var rec RecordType
+	var prevTm int64
+	dec := json.NewDecoder(r)
+	for err == nil {
+		rec = RecordType{}
+		err = dec.Decode(&rec)
+		if err == nil {
+			if speed > 0 {
+				if prevTm > 0 && rec.Time > prevTm {
+					wait := time.Duration(float64(rec.Time-prevTm) * float64(time.Millisecond) / speed)
+					select {
+					case <-time.After(wait):
+					case <-ctx.Done():
+						err = ctx.Err()
+					}
+				}
+			} else {
+				select {
+				case <-dsh.stepChan:
+				case <-ctx.Done():
+					err = ctx.Err()
+				}
+			}
+			prevTm = rec.Time
+		}
+		if err == nil {
+			up := updateType{id: rec.ID, str: rec.Str, val: rec.Val, ok: rec.OK, style: rec.Style,
+				level: rec.Level, src: rec.Src, cells: rec.Cells, del: rec.Del}
+			select {
+			case dsh.updateChan <- up:
+			case <-ctx.Done():
+				err = ctx.Err()
+			}
+		}
+	}
+	if err == io.EOF {
+		err = nil
+	}
+	return
+}
+
+// Step advances a replay that was started with the ReplayStep speed by one
+// update. If the replay is not yet waiting, one step is held until it is;
+// further calls made before that step is taken have no effect.
+func (dsh *Dashboard) Step() {
+	select {
+	case dsh.stepChan <- struct{}{}:
+	default:
+	}
+}
+
+// SetRecorder arranges for the updates of the default dashboard to be
+// recorded. See Dashboard.SetRecorder() for details.
+func SetRecorder(w io.Writer) {
+	std.SetRecorder(w)
+}
+
+// Replay plays a recording back through the default dashboard. See
+// Dashboard.Replay() for details.
+func Replay(ctx context.Context, r io.Reader, speed float64) error {
+	return std.Replay(ctx, r, speed)
+}
+
+// Step advances a stepped replay of the default dashboard by one update.
+func Step() {
+	std.Step()
+}
@@ -0,0 +1,316 @@
+package dashboard
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell"
+)
+
+// Snapshot formats
+const (
+	SnapshotText = iota // plain text, one line per screen row
+	SnapshotANSI        // text with ANSI escape sequences for colours and attributes
+	SnapshotHTML        // standalone HTML document
+	SnapshotSVG         // standalone SVG image
+)
+
+// Snapshot colours used where a cell uses the terminal's default colour
+const (
+	snapFgStr = "#d0d0d0"
+	snapBgStr = "#000000"
+)
+
+// Dimensions, in pixels, of a character cell in SVG snapshots
+const (
+	snapCellWd = 9
+	snapCellHt = 18
+)
+
+// snapCellType is a captured screen cell.
+type snapCellType struct {
+	str string      // rune and any combining runes, empty for second half of wide rune
+	st  tcell.Style // style of cell
+}
+
+// snapType holds the captured screen cells. Each row has one cell per screen
+// column.
+type snapType struct {
+	wd, ht  int              // screen size
+	rowList [][]snapCellType // screen rows
+}
+
+// snapRunType is a sequence of cells in the same row that share a style.
+type snapRunType struct {
+	x, wd int         // starting column and number of columns
+	str   string      // text of cells
+	st    tcell.Style // shared style
+}
+
+// snapCapture returns the current contents of the dashboard screen. It is
+// called by the 'dashboard show' goroutine.
+func (dsh *Dashboard) snapCapture() (snap *snapType) {
+	snap = &snapType{}
+	snap.wd, snap.ht = dsh.screen.Size()
+	snap.rowList = make([][]snapCellType, snap.ht)
+	for y := 0; y < snap.ht; y++ {
+		row := make([]snapCellType, snap.wd)
+		for x := 0; x < snap.wd; x++ {
+			mainc, combc, st, wd := dsh.screen.GetContent(x, y)
+			if mainc == 0 {
+				mainc = ' '
+			}
+			row[x] = snapCellType{str: string(append([]rune{mainc}, combc...)), st: st}
+			if wd > 1 && x+1 < snap.wd {
+				x++
+				row[x] = snapCellType{st: st}
+			}
+		}
+		snap.rowList[y] = row
+	}
+	return
+}
+
+// snapRuns returns the cells of row y of snap grouped by style. If trim is
+// true, trailing blanks in the default style are omitted.
+func snapRuns(snap *snapType, y int, trim bool) (list []snapRunType) {
+	row := snap.rowList[y]
+	lim := len(row)
+	if trim {
+		for lim > 0 && row[lim-1].str == " " && row[lim-1].st == tcell.StyleDefault {
+			lim--
+		}
+	}
+	for x := 0; x < lim; x++ {
+		cell := row[x]
+		last := len(list) - 1
+		if last >= 0 && list[last].st == cell.st {
+			list[last].str += cell.str
+			list[last].wd++
+		} else {
+			list = append(list, snapRunType{x: x, wd: 1, str: cell.str, st: cell.st})
+		}
+	}
+	return
+}
+
+// snapColor returns the colour specified by clr in "#rrggbb" form, or defStr
+// if clr is the terminal's default colour.
+func snapColor(clr tcell.Color, defStr string) string {
+	if clr == tcell.ColorDefault {
+		return defStr
+	}
+	r, g, b := clr.RGB()
+	if r < 0 {
+		return defStr
+	}
+	return fmt.Sprintf("#%02x%02x%02x", r, g, b)
+}
+
+// snapColors returns the foreground and background colours of st, in
+// "#rrggbb" form, with reverse video applied.
+func snapColors(st tcell.Style) (fgStr, bgStr string) {
+	fg, bg, attr := st.Decompose()
+	fgStr = snapColor(fg, snapFgStr)
+	bgStr = snapColor(bg, snapBgStr)
+	if attr&tcell.AttrReverse != 0 {
+		fgStr, bgStr = bgStr, fgStr
+	}
+	return
+}
+
+// snapSGR returns the ANSI escape sequence that selects the style specified
+// by st.
+func snapSGR(st tcell.Style) string {
+	list := []string{"0"}
+	fg, bg, attr := st.Decompose()
+	for _, a := range []struct {
+		mask tcell.AttrMask
+		str  string
+	}{{tcell.AttrBold, "1"}, {tcell.AttrDim, "2"}, {tcell.AttrUnderline, "4"},
+		{tcell.AttrBlink, "5"}, {tcell.AttrReverse, "7"}} {
+		if attr&a.mask != 0 {
+			list = append(list, a.str)
+		}
+	}
+	if r, g, b := fg.RGB(); fg != tcell.ColorDefault && r >= 0 {
+		list = append(list, fmt.Sprintf("38;2;%d;%d;%d", r, g, b))
+	}
+	if r, g, b := bg.RGB(); bg != tcell.ColorDefault && r >= 0 {
+		list = append(list, fmt.Sprintf("48;2;%d;%d;%d", r, g, b))
+	}
+	return "\x1b[" + strings.Join(list, ";") + "m"
+}
+
+// snapCSS returns the inline CSS declarations for the style specified by st.
+func snapCSS(st tcell.Style) string {
+	var list []string
+	_, _, attr := st.Decompose()
+	fgStr, bgStr := snapColors(st)
+	if fgStr != snapFgStr {
+		list = append(list, "color:"+fgStr)
+	}
+	if bgStr != snapBgStr {
+		list = append(list, "background-color:"+bgStr)
+	}
+	if attr&tcell.AttrBold != 0 {
+		list = append(list, "font-weight:bold")
+	}
+	if attr&tcell.AttrDim != 0 {
+		list = append(list, "opacity:0.6")
+	}
+	if attr&tcell.AttrUnderline != 0 {
+		list = append(list, "text-decoration:underline")
+	}
+	return strings.Join(list, ";")
+}
+
+// snapWrite writes snap to w in the format specified by format.
+func snapWrite(w io.Writer, snap *snapType, format int) (err error) {
+	wr := bufio.NewWriter(w)
+	switch format {
+	case SnapshotText:
+		for y := range snap.rowList {
+			for _, run := range snapRuns(snap, y, true) {
+				wr.WriteString(run.str)
+			}
+			wr.WriteString("\n")
+		}
+	case SnapshotANSI:
+		for y := range snap.rowList {
+			for _, run := range snapRuns(snap, y, true) {
+				wr.WriteString(snapSGR(run.st))
+				wr.WriteString(run.str)
+			}
+			wr.WriteString("\x1b[0m\n")
+		}
+	case SnapshotHTML:
+		fmt.Fprintf(wr, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n"+
+			"<title>Dashboard %s</title>\n</head>\n"+
+			"<body style=\"background-color:%s\">\n<pre style=\"color:%s;font-family:monospace\">",
+			time.Now().Format("2006-01-02 15:04:05"), snapBgStr, snapFgStr)
+		for y := range snap.rowList {
+			for _, run := range snapRuns(snap, y, true) {
+				cssStr := snapCSS(run.st)
+				if cssStr == "" {
+					wr.WriteString(html.EscapeString(run.str))
+				} else {
+					fmt.Fprintf(wr, "<span style=\"%s\">%s</span>", cssStr, html.EscapeString(run.str))
+				}
+			}
+			wr.WriteString("\n")
+		}
+		wr.WriteString("</pre>\n</body>\n</html>\n")
+	case SnapshotSVG:
+		fmt.Fprintf(wr, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" "+
+			"font-family=\"monospace\" font-size=\"%d\">\n<rect width=\"100%%\" height=\"100%%\" fill=\"%s\"/>\n",
+			snap.wd*snapCellWd, snap.ht*snapCellHt, snapCellHt*5/6, snapBgStr)
+		for y := range snap.rowList {
+			for _, run := range snapRuns(snap, y, true) {
+				_, _, attr := run.st.Decompose()
+				fgStr, bgStr := snapColors(run.st)
+				x := run.x * snapCellWd
+				wd := run.wd * snapCellWd
+				if bgStr != snapBgStr {
+					fmt.Fprintf(wr, "<rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" fill=\"%s\"/>\n",
+						x, y*snapCellHt, wd, snapCellHt, bgStr)
+				}
+				if strings.TrimSpace(run.str) != "" {
+					fmt.Fprintf(wr, "<text x=\"%d\" y=\"%d\" fill=\"%s\" textLength=\"%d\" "+
+						"lengthAdjust=\"spacingAndGlyphs\" xml:space=\"preserve\"", x, (y+1)*snapCellHt-4, fgStr, wd)
+					if attr&tcell.AttrBold != 0 {
+						wr.WriteString(" font-weight=\"bold\"")
+					}
+					if attr&tcell.AttrDim != 0 {
+						wr.WriteString(" opacity=\"0.6\"")
+					}
+					if attr&tcell.AttrUnderline != 0 {
+						wr.WriteString(" text-decoration=\"underline\"")
+					}
+					fmt.Fprintf(wr, ">%s</text>\n", html.EscapeString(run.str))
+				}
+			}
+		}
+		wr.WriteString("</svg>\n")
+	default:
+		err = fmt.Errorf("unrecognized snapshot format %d", format)
+	}
+	if err == nil {
+		err = wr.Flush()
+	}
+	return
+}
+
+// Snapshot writes the current contents of the dashboard screen, including any
+// help overlay, prompt or menu, to w. The format is specified by format:
+// SnapshotText writes plain text, SnapshotANSI writes text with the escape
+// sequences needed to reproduce colours and attributes in a terminal,
+// SnapshotHTML writes a standalone HTML document, and SnapshotSVG writes a
+// standalone SVG image. An error is returned if the dashboard is not running
+// or is headless.
+func (dsh *Dashboard) Snapshot(w io.Writer, format int) (err error) {
+	var snap *snapType
+	dsh.activeMtx.Lock()
+	active, done := dsh.active, dsh.doneChan
+	dsh.activeMtx.Unlock()
+	if active && dsh.out == nil {
+		snapChan := make(chan *snapType, 1)
+		dsh.control(updateType{id: updateSnapshot, snap: snapChan})
+		select {
+		case snap = <-snapChan:
+		case <-done:
+			// The dashboard stopped before taking the snapshot
+		}
+	}
+	if snap == nil {
+		return errors.New("dashboard snapshot requires a running screen")
+	}
+	return snapWrite(w, snap, format)
+}
+
+// BindSnapshot arranges for a snapshot to be written to a file in the
+// directory specified by dirStr when the key specified by key, rn and mod is
+// pressed. See BindKey() for a description of these arguments. The file is
+// named after the current time, for example "dashboard-20200314-155926.html",
+// with an extension that corresponds to format; see Snapshot(). Errors are
+// written to the standard logger.
+func (dsh *Dashboard) BindSnapshot(key tcell.Key, rn rune, mod tcell.ModMask, format int, dirStr string) {
+	dsh.BindKey(key, rn, mod, "Save snapshot", func() {
+		var err error
+		var fl *os.File
+		extStr := map[int]string{SnapshotText: "txt", SnapshotANSI: "ans",
+			SnapshotHTML: "html", SnapshotSVG: "svg"}[format]
+		nameStr := filepath.Join(dirStr, "dashboard-"+time.Now().Format("20060102-150405")+"."+extStr)
+		fl, err = os.Create(nameStr)
+		if err == nil {
+			err = dsh.Snapshot(fl, format)
+			closeErr := fl.Close()
+			if err == nil {
+				err = closeErr
+			}
+		}
+		if err != nil {
+			log.Printf("snapshot %s: %s", nameStr, err)
+		}
+	})
+}
+
+// Snapshot writes the contents of the default dashboard's screen to w. See
+// Dashboard.Snapshot() for details.
+func Snapshot(w io.Writer, format int) error {
+	return std.Snapshot(w, format)
+}
+
+// BindSnapshot binds a key that saves a snapshot of the default dashboard.
+// See Dashboard.BindSnapshot() for details.
+func BindSnapshot(key tcell.Key, rn rune, mod tcell.ModMask, format int, dirStr string) {
+	std.BindSnapshot(key, rn, mod, format, dirStr)
+}
@@ -0,0 +1,83 @@
+package dashboard
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// statsUpdate records a screen refresh (or headless snapshot) at the time
+// specified by tm and assigns current statistics to the stats fields. The
+// fields whose text has changed are returned.
+func (dsh *Dashboard) statsUpdate(tm time.Time) (list []fieldPtrType) {
+	dsh.frameCount++
+	if elapsed := tm.Sub(dsh.statTm); elapsed >= time.Second {
+		dsh.frameRate = float64(dsh.frameCount) / elapsed.Seconds()
+		dsh.frameCount = 0
+		dsh.statTm = tm
+	}
+	dropped := dsh.Dropped()
+	str := fmt.Sprintf("%d received, %d dropped, %d queued, %.1f frames/s",
+		dsh.received, dropped, len(dsh.updateChan), dsh.frameRate)
+	dsh.fieldMtx.Lock()
+	for _, fieldPtr := range dsh.fieldMap {
+		if fieldPtr.item == itemStats && fieldPtr.valStr != str {
+			fieldPtr.valStr = str
+			fieldPtr.style = StyleValue
+			if dropped > 0 {
+				fieldPtr.style = StyleWarn
+			}
+			fieldPtr.count = 1
+			list = append(list, fieldPtr)
+		}
+	}
+	dsh.fieldMtx.Unlock()
+	return
+}
+
+// RegisterStats registers a built-in key/value field that reports the
+// dashboard's own activity: the number of field updates received and
+// dropped, the number waiting to be processed, and the screen refresh rate.
+// The identifier, coordinates, width and key are specified as with
+// RegisterKeyVal(). The value is shown with the StyleWarn style once any
+// update has been dropped. It is refreshed along with the screen, so it does
+// not change while the application is idle.
+func (dsh *Dashboard) RegisterStats(id, x, y, wd int, keyStr string) {
+	dsh.fieldRegister(id, &fieldType{id: id, item: itemStats, x: x, y: y, wd: wd, str: keyStr})
+}
+
+// Dropped returns the number of field updates that have been discarded
+// because the dashboard could not keep up with them. It may be called safely
+// from other goroutines.
+func (dsh *Dashboard) Dropped() int64 {
+	return atomic.LoadInt64(&dsh.dropped)
+}
+
+// SetFrameRate sets the maximum number of times per second the screen is
+// refreshed. The default is 30. A value of zero or less refreshes the screen
+// as soon as updates have been processed. This method must be called before
+// Run().
+func (dsh *Dashboard) SetFrameRate(fps int) {
+	dsh.frame = 0
+	if fps > 0 {
+		dsh.frame = time.Second / time.Duration(fps)
+	}
+}
+
+// RegisterStats registers a statistics field with the default dashboard. See
+// Dashboard.RegisterStats() for details.
+func RegisterStats(id, x, y, wd int, keyStr string) {
+	std.RegisterStats(id, x, y, wd, keyStr)
+}
+
+// Dropped returns the number of field updates discarded by the default
+// dashboard.
+func Dropped() int64 {
+	return std.Dropped()
+}
+
+// SetFrameRate sets the maximum refresh rate of the default dashboard. See
+// Dashboard.SetFrameRate() for details.
+func SetFrameRate(fps int) {
+	std.SetFrameRate(fps)
+}
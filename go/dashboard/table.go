@@ -0,0 +1,340 @@
+package dashboard
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell"
+	"github.com/jung-kurt/etc/go/util"
+)
+
+// Table column alignments
+const (
+	AlignLeft = iota
+	AlignRight
+	AlignCenter
+)
+
+// ColumnType describes a column of a table field. Title is shown in the
+// header row. Width is the number of columns used by the cells; columns with
+// a zero or negative width share the space left over by the others. Align is
+// one of AlignLeft, AlignRight or AlignCenter. Format is the fmt verb, for
+// example "%.2f", used to convert cell values to text; "%v" is used if it is
+// empty. Cells that are too wide are truncated with "..".
+type ColumnType struct {
+	Title  string
+	Width  int
+	Align  int
+	Format string
+}
+
+// tableRowType is a row of a table field.
+type tableRowType struct {
+	keyStr  string   // application key used to update and remove the row
+	cellStr []string // formatted cells, one per column
+}
+
+// tableUpdate inserts or replaces the row of the table field specified by fld
+// that is named by up.str. Values are formatted according to the column
+// definitions, unless up.cells holds cells that have already been formatted.
+// If up.del is set, the row is removed instead.
+func tableUpdate(fld fieldPtrType, up updateType) {
+	pos := -1
+	for j := range fld.rowList {
+		if fld.rowList[j].keyStr == up.str {
+			pos = j
+		}
+	}
+	if up.del {
+		if pos >= 0 {
+			fld.rowList = append(fld.rowList[:pos], fld.rowList[pos+1:]...)
+		}
+		return
+	}
+	row := tableRowType{keyStr: up.str, cellStr: make([]string, len(fld.cols))}
+	for j, col := range fld.cols {
+		if up.cells != nil {
+			if j < len(up.cells) {
+				row.cellStr[j] = up.cells[j]
+			}
+		} else if j < len(up.vals) {
+			format := col.Format
+			if format == "" {
+				format = "%v"
+			}
+			row.cellStr[j] = fmt.Sprintf(format, up.vals[j])
+		}
+	}
+	if pos >= 0 {
+		fld.rowList[pos] = row
+	} else {
+		fld.rowList = append(fld.rowList, row)
+	}
+}
+
+// tableCells returns the formatted cells of the row identified by keyStr in
+// the table field specified by fld, or nil if there is no such row.
+func tableCells(fld fieldPtrType, keyStr string) []string {
+	for j := range fld.rowList {
+		if fld.rowList[j].keyStr == keyStr {
+			return fld.rowList[j].cellStr
+		}
+	}
+	return nil
+}
+
+// tableLess compares cells numerically if both hold numbers, otherwise as
+// strings.
+func tableLess(aStr, bStr string) bool {
+	aStr = strings.TrimSpace(aStr)
+	bStr = strings.TrimSpace(bStr)
+	a, errA := strconv.ParseFloat(aStr, 64)
+	b, errB := strconv.ParseFloat(bStr, 64)
+	if errA == nil && errB == nil {
+		return a < b
+	}
+	return aStr < bStr
+}
+
+// tableOrder returns the positions of the rows of the table field specified
+// by fld in display order. Rows are shown in the order they were first added
+// unless a sort column has been selected.
+func tableOrder(fld fieldPtrType) (list []int) {
+	list = make([]int, len(fld.rowList))
+	for j := range list {
+		list[j] = j
+	}
+	col := fld.sortCol
+	if col >= 0 && col < len(fld.cols) {
+		sort.SliceStable(list, func(a, b int) bool {
+			aStr := fld.rowList[list[a]].cellStr[col]
+			bStr := fld.rowList[list[b]].cellStr[col]
+			if fld.sortDesc {
+				return tableLess(bStr, aStr)
+			}
+			return tableLess(aStr, bStr)
+		})
+	}
+	return
+}
+
+// tableWidths returns the cell widths of the columns of the table field
+// specified by fld when the table is wd columns wide. A single blank
+// separates columns.
+func tableWidths(fld fieldPtrType, wd int) (wdList []int) {
+	var flex int
+	remain := wd - len(fld.cols) + 1
+	wdList = make([]int, len(fld.cols))
+	for j, col := range fld.cols {
+		if col.Width > 0 {
+			wdList[j] = col.Width
+			remain -= col.Width
+		} else {
+			flex++
+		}
+	}
+	for j, col := range fld.cols {
+		if col.Width <= 0 {
+			wdList[j] = remain / flex
+			if wdList[j] < 1 {
+				wdList[j] = 1
+			}
+			remain -= wdList[j]
+			flex--
+		}
+	}
+	return
+}
+
+// tableCell returns str truncated or padded to wd columns with the alignment
+// specified by align.
+func tableCell(str string, wd, align int) string {
+	str = util.StrTruncate(str, wd, "..")
+	switch align {
+	case AlignRight:
+		return util.StrPad(str, -wd)
+	case AlignCenter:
+		str = strings.Repeat(" ", (wd-util.StrWidth(str))/2) + str
+	}
+	return util.StrPad(str, wd)
+}
+
+// tableRows returns the number of data rows shown by the table field
+// specified by fld.
+func tableRows(fld fieldPtrType) int {
+	rows := fld.ht - 1
+	if rows > len(fld.rowList) {
+		rows = len(fld.rowList)
+	}
+	if rows < 0 {
+		rows = 0
+	}
+	return rows
+}
+
+// tableScroll moves the view of the table field specified by fld down by
+// delta rows, or up if delta is negative.
+func tableScroll(fld fieldPtrType, delta int) {
+	fld.scroll += delta
+	max := len(fld.rowList) - tableRows(fld)
+	if fld.scroll > max {
+		fld.scroll = max
+	}
+	if fld.scroll < 0 {
+		fld.scroll = 0
+	}
+}
+
+// tablePut draws the header and visible rows of the table field specified by
+// fld. The header of the sort column, if any, is marked with an arrow. A
+// field with keyboard focus shows a scroll bar in its rightmost column.
+func (dsh *Dashboard) tablePut(scrWd int, fld fieldPtrType) {
+	thm := &dsh.theme
+	focused := dsh.focusOK && dsh.focusID == fld.id
+	lim := fld.x + fieldWidth(fld, scrWd)
+	if lim > scrWd {
+		lim = scrWd
+	}
+	if focused {
+		lim--
+	}
+	if fld.ht < 1 || lim <= fld.x {
+		return
+	}
+	tableScroll(fld, 0)
+	wdList := tableWidths(fld, lim-fld.x)
+	x := fld.x
+	for j, col := range fld.cols {
+		titleStr := col.Title
+		if j == fld.sortCol {
+			titleStr += util.StrIf(fld.sortDesc, string(tcell.RuneDArrow), string(tcell.RuneUArrow))
+		}
+		x = dsh.put(thm.Banner, x, fld.y, lim, tableCell(titleStr, wdList[j], col.Align), " ")
+	}
+	dsh.put(thm.Banner, x, fld.y, lim, util.StrPad("", lim-x))
+	order := tableOrder(fld)
+	rows := tableRows(fld)
+	for j := 0; j < fld.ht-1; j++ {
+		x = fld.x
+		y := fld.y + 1 + j
+		if j < rows {
+			row := &fld.rowList[order[fld.scroll+j]]
+			for k, col := range fld.cols {
+				x = dsh.put(thm.Value, x, y, lim, tableCell(row.cellStr[k], wdList[k], col.Align), " ")
+			}
+		}
+		dsh.put(thm.Value, x, y, lim, util.StrPad("", lim-x))
+	}
+	if focused {
+		dsh.scrollBarPut(thm.Key, lim, fld.y+1, fld.ht-1, fld.scroll, len(fld.rowList), rows)
+	}
+}
+
+// tableSortNext selects the next sort order of the table field specified by
+// fld: ascending by the first column, then descending, then ascending by the
+// next column and so on, and finally the order in which rows were added.
+func tableSortNext(fld fieldPtrType) {
+	if fld.sortCol >= 0 && !fld.sortDesc {
+		fld.sortDesc = true
+	} else {
+		fld.sortDesc = false
+		fld.sortCol++
+		if fld.sortCol >= len(fld.cols) {
+			fld.sortCol = -1
+		}
+	}
+}
+
+// tableKey responds to the key event specified by ev on behalf of the table
+// field specified by fld, which has keyboard focus. The arrow, page, Home and
+// End keys scroll the rows and 's' selects the next sort order. handled is
+// false if ev does not apply.
+func (dsh *Dashboard) tableKey(fld fieldPtrType, ev *tcell.EventKey) (handled bool) {
+	page := fld.ht - 1
+	handled = true
+	switch ev.Key() {
+	case tcell.KeyUp:
+		tableScroll(fld, -1)
+	case tcell.KeyDown:
+		tableScroll(fld, 1)
+	case tcell.KeyPgUp:
+		tableScroll(fld, -page)
+	case tcell.KeyPgDn:
+		tableScroll(fld, page)
+	case tcell.KeyHome:
+		fld.scroll = 0
+	case tcell.KeyEnd:
+		tableScroll(fld, len(fld.rowList))
+	case tcell.KeyRune:
+		if ev.Rune() == 's' {
+			tableSortNext(fld)
+		} else {
+			handled = false
+		}
+	default:
+		handled = false
+	}
+	if handled {
+		scrWd, _ := dsh.screen.Size()
+		dsh.tablePut(scrWd, fld)
+		dsh.screen.Show()
+	}
+	return
+}
+
+// tableText returns the rows of the table field specified by fld, in display
+// order, as a single line.
+func tableText(fld fieldPtrType) string {
+	var list []string
+	for _, j := range tableOrder(fld) {
+		row := &fld.rowList[j]
+		list = append(list, row.keyStr+": "+strings.Join(row.cellStr, " "))
+	}
+	return strings.Join(list, "; ")
+}
+
+// RegisterTable registers a dashboard table field with the identifier
+// specified by id. Its coordinates are specified by x and y, and its width by
+// wd; a zero value indicates the full width of the screen, and a negative
+// value indicates the position from the right. The field occupies ht rows: a
+// header row followed by ht-1 data rows. The columns are described by cols.
+// When there are more rows than fit, the user can focus the table with Tab
+// and scroll through them. Pressing 's' while the table has focus cycles
+// through the sort orders.
+func (dsh *Dashboard) RegisterTable(id, x, y, wd, ht int, cols ...ColumnType) {
+	dsh.fieldRegister(id, &fieldType{id: id, item: itemTable, x: x, y: y, wd: wd, ht: ht,
+		cols: cols, sortCol: -1})
+}
+
+// UpdateTableRow sets the cells of the row identified by keyStr in the table
+// field specified by id. The row is added if it does not already exist. The
+// values specified by vals are assigned to the columns in order and formatted
+// as described by the column definitions.
+func (dsh *Dashboard) UpdateTableRow(id int, keyStr string, vals ...interface{}) {
+	dsh.send(updateType{id: id, str: keyStr, vals: vals})
+}
+
+// RemoveTableRow removes the row identified by keyStr from the table field
+// specified by id.
+func (dsh *Dashboard) RemoveTableRow(id int, keyStr string) {
+	dsh.send(updateType{id: id, str: keyStr, del: true})
+}
+
+// RegisterTable registers a table field with the default dashboard. See
+// Dashboard.RegisterTable() for details.
+func RegisterTable(id, x, y, wd, ht int, cols ...ColumnType) {
+	std.RegisterTable(id, x, y, wd, ht, cols...)
+}
+
+// UpdateTableRow sets a row of a table field of the default dashboard. See
+// Dashboard.UpdateTableRow() for details.
+func UpdateTableRow(id int, keyStr string, vals ...interface{}) {
+	std.UpdateTableRow(id, keyStr, vals...)
+}
+
+// RemoveTableRow removes a row from a table field of the default dashboard.
+func RemoveTableRow(id int, keyStr string) {
+	std.RemoveTableRow(id, keyStr)
+}
@@ -0,0 +1,147 @@
+package dashboard
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell"
+	"github.com/jung-kurt/etc/go/util"
+)
+
+// Value styles used with UpdateKeyValStyle()
+const (
+	StyleValue = iota // normal value
+	StyleWarn         // value that warrants attention
+	StyleError        // critical value
+	StyleDim          // value of lesser importance
+)
+
+// ThemeType groups the styles used to draw dashboard fields. Key is used for
+// static keys, labels and borders, Value for field values, and Banner for
+// headers and panel titles. Warn, Error and Dim are alternative value styles
+// selected with UpdateKeyValStyle(). OK is used for progress bars and
+// successful activity indicator updates; Error is also used for unsuccessful
+// ones.
+type ThemeType struct {
+	Key    tcell.Style
+	Value  tcell.Style
+	Banner tcell.Style
+	Warn   tcell.Style
+	Error  tcell.Style
+	Dim    tcell.Style
+	OK     tcell.Style
+}
+
+// StyleSpecType is the JSON representation of a style. Colours are specified
+// by name (for example, "yellow") or in "#rrggbb" form; an empty string
+// selects the terminal's default colour.
+type StyleSpecType struct {
+	Fg        string `json:"fg,omitempty"`
+	Bg        string `json:"bg,omitempty"`
+	Bold      bool   `json:"bold,omitempty"`
+	Dim       bool   `json:"dim,omitempty"`
+	Reverse   bool   `json:"reverse,omitempty"`
+	Underline bool   `json:"underline,omitempty"`
+}
+
+// Style returns the tcell style described by spec.
+func (spec StyleSpecType) Style() (st tcell.Style) {
+	st = tcell.StyleDefault
+	if spec.Fg != "" {
+		st = st.Foreground(tcell.GetColor(spec.Fg))
+	}
+	if spec.Bg != "" {
+		st = st.Background(tcell.GetColor(spec.Bg))
+	}
+	return st.Bold(spec.Bold).Dim(spec.Dim).Reverse(spec.Reverse).Underline(spec.Underline)
+}
+
+// DefaultTheme returns the colour theme used by dashboards on terminals that
+// support at least eight colours.
+func DefaultTheme() ThemeType {
+	st := tcell.StyleDefault
+	return ThemeType{
+		Key:    st.Foreground(tcell.ColorYellow),
+		Value:  st.Foreground(tcell.ColorWhite),
+		Banner: st.Foreground(tcell.ColorBlack).Background(tcell.ColorYellow),
+		Warn:   st.Foreground(tcell.ColorOrange).Bold(true),
+		Error:  st.Foreground(tcell.ColorRed).Bold(true),
+		Dim:    st.Foreground(tcell.ColorGray),
+		OK:     st.Foreground(tcell.ColorGreen),
+	}
+}
+
+// MonoTheme returns a theme that relies only on text attributes. It is used
+// by dashboards on terminals that do not support colour.
+func MonoTheme() ThemeType {
+	st := tcell.StyleDefault
+	return ThemeType{
+		Key:    st,
+		Value:  st.Bold(true),
+		Banner: st.Reverse(true),
+		Warn:   st.Bold(true).Underline(true),
+		Error:  st.Bold(true).Reverse(true),
+		Dim:    st.Dim(true),
+		OK:     st.Bold(true),
+	}
+}
+
+// LoadTheme reads a JSON-encoded theme from the file specified by fileStr.
+// The file holds an object that maps style names ("key", "value", "banner",
+// "warn", "error", "dim" and "ok") to StyleSpecType values. Styles that are
+// not named keep their values from DefaultTheme().
+func LoadTheme(fileStr string) (thm ThemeType, err error) {
+	var specMap map[string]StyleSpecType
+
+	thm = DefaultTheme()
+	err = util.JSONGetFile(fileStr, &specMap)
+	for name, spec := range specMap {
+		if err == nil {
+			switch name {
+			case "key":
+				thm.Key = spec.Style()
+			case "value":
+				thm.Value = spec.Style()
+			case "banner":
+				thm.Banner = spec.Style()
+			case "warn":
+				thm.Warn = spec.Style()
+			case "error":
+				thm.Error = spec.Style()
+			case "dim":
+				thm.Dim = spec.Style()
+			case "ok":
+				thm.OK = spec.Style()
+			default:
+				err = fmt.Errorf("unrecognized theme style \"%s\"", name)
+			}
+		}
+	}
+	return
+}
+
+// style returns the value style specified by style.
+func (thm *ThemeType) style(style int) tcell.Style {
+	switch style {
+	case StyleWarn:
+		return thm.Warn
+	case StyleError:
+		return thm.Error
+	case StyleDim:
+		return thm.Dim
+	}
+	return thm.Value
+}
+
+// SetTheme assigns thm to the dashboard and redraws it. The theme is kept if
+// the dashboard is run again. Otherwise, a dashboard uses DefaultTheme(), or
+// MonoTheme() if the terminal does not support colour; MonoTheme() is also
+// used in place of an assigned theme on such terminals.
+func (dsh *Dashboard) SetTheme(thm ThemeType) {
+	dsh.control(updateType{id: updateTheme, thm: &thm})
+}
+
+// SetTheme assigns a theme to the default dashboard. See Dashboard.SetTheme()
+// for details.
+func SetTheme(thm ThemeType) {
+	std.SetTheme(thm)
+}
@@ -0,0 +1,178 @@
+package longpoll
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	cnMaxTimeout = 120 // longest wait, in seconds, that a client may request
+)
+
+// EventType is a single published event. Timestamp is the time the event was
+// published, in milliseconds since the Unix epoch; several events may share
+// the same timestamp. ID is a sequence number that increases strictly from
+// one event to the next, in any category, so that clients can use it to
+// request subsequent events without missing or repeating any.
+type EventType struct {
+	ID        int64       `json:"id"`
+	Timestamp int64       `json:"timestamp"`
+	Category  string      `json:"category"`
+	Data      interface{} `json:"data"`
+}
+
+// Manager implements the server side of the long-polling protocol used by
+// js/longpoll.js. Events are published by category and retained in a bounded
+// per-category history. A client requests the events of a category that are
+// newer than a given time; if there are none, the request waits until one is
+// published or the client's timeout elapses. Manager implements the
+// http.Handler interface. Its methods may be called safely from multiple
+// goroutines.
+type Manager struct {
+	mtx       sync.Mutex
+	histMap   map[string][]EventType // retained events by category, oldest first
+	maxEvents int                    // number of events retained per category
+	lastID    int64                  // sequence number of most recent event
+	notify    chan struct{}          // closed and replaced when an event is published
+}
+
+// New returns a manager that retains up to maxEvents of the most recent
+// events in each category.
+func New(maxEvents int) (m *Manager) {
+	if maxEvents < 1 {
+		maxEvents = 1
+	}
+	m = &Manager{maxEvents: maxEvents}
+	m.histMap = make(map[string][]EventType)
+	m.notify = make(chan struct{})
+	return
+}
+
+// Publish adds an event with the specified category and data to the history
+// and wakes any requests that are waiting for it. The data must be
+// JSON-encodable.
+func (m *Manager) Publish(category string, data interface{}) {
+	m.mtx.Lock()
+	m.lastID++
+	ev := EventType{ID: m.lastID, Timestamp: msNow(), Category: category, Data: data}
+	list := append(m.histMap[category], ev)
+	if len(list) > m.maxEvents {
+		list = append(list[:0:0], list[len(list)-m.maxEvents:]...)
+	}
+	m.histMap[category] = list
+	close(m.notify)
+	m.notify = make(chan struct{})
+	m.mtx.Unlock()
+}
+
+// Events returns the retained events in the specified category that have a
+// timestamp later than sinceTime and an ID greater than sinceID, oldest first.
+// It also returns a channel that is closed when the next event in any
+// category is published.
+func (m *Manager) Events(category string, sinceTime, sinceID int64) (list []EventType, notify <-chan struct{}) {
+	m.mtx.Lock()
+	for _, ev := range m.histMap[category] {
+		if ev.Timestamp > sinceTime && ev.ID > sinceID {
+			list = append(list, ev)
+		}
+	}
+	notify = m.notify
+	m.mtx.Unlock()
+	return
+}
+
+// msNow returns the current time in milliseconds since the Unix epoch.
+func msNow() int64 {
+	return time.Now().UnixNano() / int64(time.Millisecond)
+}
+
+// paramInt returns the integer value of the URL query parameter specified by
+// key, or def if the parameter is absent.
+func paramInt(r *http.Request, key string, def int64) (val int64, err error) {
+	str := r.URL.Query().Get(key)
+	if str == "" {
+		val = def
+	} else {
+		val, err = strconv.ParseInt(str, 10, 64)
+	}
+	return
+}
+
+func reply(w http.ResponseWriter, val interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	json.NewEncoder(w).Encode(val)
+}
+
+func replyError(w http.ResponseWriter, errStr string) {
+	reply(w, struct {
+		Error string `json:"error"`
+	}{errStr})
+}
+
+// ServeHTTP implements the http.Handler interface. The request must include a
+// category parameter; since_time (milliseconds since the Unix epoch, default
+// zero), since_id (the ID of the last event received, default zero) and
+// timeout (seconds, default and maximum 120) are optional. The js/longpoll.js
+// client passes the current time as since_time until it receives its first
+// event, and the timestamp of the most recent event thereafter. Since several
+// events may share a timestamp, a response to a request without since_id is
+// held back until the millisecond of its last event has passed, so that no
+// event with that timestamp is published after the client has seen it. A
+// client may instead pass the ID of the most recent event as since_id. The
+// response is {"events":[...]} if events are available before the timeout
+// elapses, {"timeout":"..."} if not, and {"error":"..."} if the request is
+// invalid.
+func (m *Manager) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var list []EventType
+	var notify <-chan struct{}
+
+	category := r.URL.Query().Get("category")
+	if category == "" {
+		replyError(w, "missing category parameter")
+		return
+	}
+	sinceTime, err := paramInt(r, "since_time", 0)
+	if err != nil {
+		replyError(w, "invalid since_time parameter")
+		return
+	}
+	sinceID, err := paramInt(r, "since_id", 0)
+	if err != nil {
+		replyError(w, "invalid since_id parameter")
+		return
+	}
+	timeout, err := paramInt(r, "timeout", cnMaxTimeout)
+	if err != nil || timeout < 1 || timeout > cnMaxTimeout {
+		replyError(w, "timeout parameter must be between 1 and "+strconv.Itoa(cnMaxTimeout))
+		return
+	}
+	timer := time.NewTimer(time.Duration(timeout) * time.Second)
+	defer timer.Stop()
+	for {
+		list, notify = m.Events(category, sinceTime, sinceID)
+		if len(list) > 0 && sinceID == 0 && list[len(list)-1].Timestamp >= msNow() {
+			time.Sleep(time.Millisecond)
+			continue
+		}
+		if len(list) > 0 {
+			reply(w, struct {
+				Events []EventType `json:"events"`
+			}{list})
+			return
+		}
+		select {
+		case <-notify:
+		case <-timer.C:
+			reply(w, struct {
+				Timeout string `json:"timeout"`
+			}{"no events before timeout"})
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
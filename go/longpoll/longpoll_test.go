@@ -0,0 +1,124 @@
+package longpoll_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jung-kurt/etc/go/longpoll"
+)
+
+type responseType struct {
+	Events  []longpoll.EventType
+	Timeout string
+	Error   string
+}
+
+func get(t *testing.T, urlStr string) (res responseType) {
+	rsp, err := http.Get(urlStr)
+	if err == nil {
+		err = json.NewDecoder(rsp.Body).Decode(&res)
+		rsp.Body.Close()
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return
+}
+
+// Requests are answered with events, a timeout or an error
+func TestManager(t *testing.T) {
+	mgr := longpoll.New(2)
+	srv := httptest.NewServer(mgr)
+	defer srv.Close()
+
+	res := get(t, srv.URL+"?timeout=1")
+	if res.Error == "" {
+		t.Fatalf("expecting error for missing category")
+	}
+	res = get(t, srv.URL+"?category=a&timeout=1")
+	if res.Timeout == "" {
+		t.Fatalf("expecting timeout")
+	}
+	for j := 0; j < 3; j++ {
+		mgr.Publish("a", j)
+	}
+	mgr.Publish("b", "other")
+	res = get(t, srv.URL+"?category=a&timeout=1")
+	if len(res.Events) != 2 || res.Events[0].Data != 1.0 || res.Events[1].Data != 2.0 {
+		t.Fatalf("expecting two most recent events, got %v", res.Events)
+	}
+	since := res.Events[1].ID
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		mgr.Publish("a", "late")
+	}()
+	res = get(t, fmt.Sprintf("%s?category=a&timeout=5&since_id=%d", srv.URL, since))
+	if len(res.Events) != 1 || res.Events[0].Data != "late" || res.Events[0].ID <= since {
+		t.Fatalf("expecting waited-for event, got %v", res.Events)
+	}
+}
+
+// Events published in a burst share real timestamps but have distinct IDs
+func TestBurst(t *testing.T) {
+	mgr := longpoll.New(200)
+	startTm := time.Now().UnixNano() / int64(time.Millisecond)
+	for j := 0; j < 100; j++ {
+		mgr.Publish("a", j)
+	}
+	endTm := time.Now().UnixNano() / int64(time.Millisecond)
+	list, _ := mgr.Events("a", 0, 0)
+	for j, ev := range list {
+		if ev.Timestamp < startTm || ev.Timestamp > endTm {
+			t.Fatalf("expecting timestamp between %d and %d, got %d", startTm, endTm, ev.Timestamp)
+		}
+		if j > 0 && ev.ID != list[j-1].ID+1 {
+			t.Fatalf("expecting consecutive IDs, got %d after %d", ev.ID, list[j-1].ID)
+		}
+	}
+	// A client that starts now receives none of the earlier events
+	list, _ = mgr.Events("a", endTm, 0)
+	if len(list) != 0 {
+		t.Fatalf("expecting no events after %d, got %d", endTm, len(list))
+	}
+	list, _ = mgr.Events("a", 0, 99)
+	if len(list) != 1 || list[0].Data != 99 {
+		t.Fatalf("expecting most recent event, got %v", list)
+	}
+}
+
+// A client that resumes from the timestamp of the last event it received
+// misses none of the events published during a burst
+func TestResume(t *testing.T) {
+	const count = 500
+	mgr := longpoll.New(count)
+	srv := httptest.NewServer(mgr)
+	defer srv.Close()
+
+	sinceTime := time.Now().UnixNano()/int64(time.Millisecond) - 1
+	go func() {
+		for j := 0; j < count; j++ {
+			mgr.Publish("a", j)
+			if j%10 == 0 {
+				time.Sleep(100 * time.Microsecond)
+			}
+		}
+	}()
+	var id int64
+	for id < count {
+		res := get(t, fmt.Sprintf("%s?category=a&timeout=5&since_time=%d", srv.URL, sinceTime))
+		if len(res.Events) == 0 {
+			t.Fatalf("expecting events after %d", id)
+		}
+		for _, ev := range res.Events {
+			if ev.ID != id+1 {
+				t.Fatalf("expecting event %d, got %d", id+1, ev.ID)
+			}
+			id = ev.ID
+			sinceTime = ev.Timestamp
+		}
+	}
+}
@@ -0,0 +1,408 @@
+package regression
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/jung-kurt/etc/go/util"
+)
+
+// Circle fitting methods
+const (
+	CircleKasa      = iota // algebraic fit, fast but biased toward small radii for short arcs
+	CirclePratt            // algebraic fit normalized by the gradient, nearly unbiased
+	CircleTaubin           // algebraic fit normalized by the mean gradient, nearly unbiased
+	CircleGeometric        // Taubin fit refined to minimize geometric distances
+)
+
+// CircleFitType holds the center, radius, and root-mean-square geometric
+// distance of the points from a fitted circle.
+type CircleFitType struct {
+	X, Y   float64
+	Radius float64
+	RMS    float64
+}
+
+// String implements the fmt Stringer interface.
+func (fit CircleFitType) String() string {
+	return fmt.Sprintf("center [%s, %s], radius %s (RMS %s)", f3(fit.X), f3(fit.Y), f3(fit.Radius), f3(fit.RMS))
+}
+
+// EllipseFitType holds the center, semi-axis lengths, and rotation of a
+// fitted ellipse. Angle is the counterclockwise angle, in radians between
+// -pi/2 and pi/2, from the x axis to the major axis. RMS is the
+// root-mean-square of the approximate geometric distances of the points from
+// the ellipse.
+type EllipseFitType struct {
+	X, Y      float64
+	SemiMajor float64
+	SemiMinor float64
+	Angle     float64
+	RMS       float64
+}
+
+// String implements the fmt Stringer interface.
+func (fit EllipseFitType) String() string {
+	return fmt.Sprintf("center [%s, %s], axes %s and %s, angle %s° (RMS %s)", f3(fit.X), f3(fit.Y),
+		f3(fit.SemiMajor), f3(fit.SemiMinor), f3(fit.Angle*180/math.Pi), f3(fit.RMS))
+}
+
+// momentType holds the centroid and central moments of a set of points, with
+// z = x*x + y*y.
+type momentType struct {
+	mx, my                 float64
+	xx, yy, xy, xz, yz, zz float64
+}
+
+// moments returns the centroid and central moments of pairs.
+func moments(pairs []util.PairType) (m momentType) {
+	count := float64(len(pairs))
+	for _, pr := range pairs {
+		m.mx += pr.X
+		m.my += pr.Y
+	}
+	m.mx /= count
+	m.my /= count
+	for _, pr := range pairs {
+		x := pr.X - m.mx
+		y := pr.Y - m.my
+		z := x*x + y*y
+		m.xx += x * x
+		m.yy += y * y
+		m.xy += x * y
+		m.xz += x * z
+		m.yz += y * z
+		m.zz += z * z
+	}
+	m.xx /= count
+	m.yy /= count
+	m.xy /= count
+	m.xz /= count
+	m.yz /= count
+	m.zz /= count
+	return
+}
+
+// circleAlgebraic returns the Pratt or Taubin circle fit of pairs. Both
+// minimize the algebraic distance subject to a normalizing constraint; the
+// smallest root of the characteristic polynomial is found with Newton's
+// method following N. Chernov, "Circular and Linear Regression" (2010).
+func circleAlgebraic(pairs []util.PairType, method int) (fit CircleFitType, err error) {
+	m := moments(pairs)
+	mz := m.xx + m.yy
+	covXY := m.xx*m.yy - m.xy*m.xy
+	varZ := m.zz - mz*mz
+	a1 := varZ*mz + 4*covXY*mz - m.xz*m.xz - m.yz*m.yz
+	a0 := m.xz*(m.xz*m.yy-m.yz*m.xy) + m.yz*(m.yz*m.xx-m.xz*m.xy) - varZ*covXY
+	var a2, a3 float64
+	if method == CirclePratt {
+		a2 = 4*covXY - 3*mz*mz - m.zz
+		a3 = 4
+	} else {
+		a2 = -3*mz*mz - m.zz
+		a3 = 4 * mz
+	}
+	poly := func(x float64) float64 {
+		if method == CirclePratt {
+			return a0 + x*(a1+x*(a2+a3*x*x))
+		}
+		return a0 + x*(a1+x*(a2+a3*x))
+	}
+	deriv := func(x float64) float64 {
+		if method == CirclePratt {
+			return a1 + x*(2*a2+4*a3*x*x)
+		}
+		return a1 + x*(2*a2+3*a3*x)
+	}
+	x, y := 0.0, a0
+	for iter := 0; iter < 100; iter++ {
+		xNew := x - y/deriv(x)
+		if xNew == x || math.IsNaN(xNew) || math.IsInf(xNew, 0) {
+			break
+		}
+		yNew := poly(xNew)
+		if math.Abs(yNew) >= math.Abs(y) {
+			break
+		}
+		x, y = xNew, yNew
+	}
+	det := x*x - x*mz + covXY
+	if det == 0 {
+		return fit, errors.New("points are collinear")
+	}
+	cx := (m.xz*(m.yy-x) - m.yz*m.xy) / det / 2
+	cy := (m.yz*(m.xx-x) - m.xz*m.xy) / det / 2
+	fit.X = cx + m.mx
+	fit.Y = cy + m.my
+	if method == CirclePratt {
+		fit.Radius = math.Sqrt(cx*cx + cy*cy + mz + 2*x)
+	} else {
+		fit.Radius = math.Sqrt(cx*cx + cy*cy + mz)
+	}
+	return
+}
+
+// circleKasa returns the Kasa circle fit of pairs, which solves
+// x*x + y*y = a*x + b*y + c by linear least squares.
+func circleKasa(pairs []util.PairType) (fit CircleFitType, err error) {
+	m := moments(pairs)
+	a := make([][]float64, len(pairs))
+	b := make([]float64, len(pairs))
+	for j, pr := range pairs {
+		x := pr.X - m.mx
+		y := pr.Y - m.my
+		a[j] = []float64{x, y, 1}
+		b[j] = x*x + y*y
+	}
+	var sol []float64
+	sol, err = leastSquares(a, b)
+	if err == nil {
+		cx := sol[0] / 2
+		cy := sol[1] / 2
+		fit.X = cx + m.mx
+		fit.Y = cy + m.my
+		fit.Radius = math.Sqrt(sol[2] + cx*cx + cy*cy)
+	} else {
+		err = errors.New("points are collinear")
+	}
+	return
+}
+
+// circleRMS returns the root-mean-square distance of pairs from the circle
+// specified by fit.
+func circleRMS(pairs []util.PairType, fit CircleFitType) float64 {
+	list := make([]float64, len(pairs))
+	for j, pr := range pairs {
+		list[j] = math.Hypot(pr.X-fit.X, pr.Y-fit.Y) - fit.Radius
+	}
+	return util.RootMeanSquare(list)
+}
+
+// CircleFit returns the center and radius of the circle that best fits the
+// points specified by pairs. Unlike Center(), the radius need not be known.
+// The method is one of CircleKasa, CirclePratt, CircleTaubin or
+// CircleGeometric. The algebraic methods are fast and need no starting point;
+// Taubin's is recommended. CircleGeometric refines the Taubin fit with the
+// Levenberg-Marquardt method to minimize the sum of squared distances of the
+// points from the circle, which is statistically best but slower. An error
+// is returned if there are fewer than three points or if they are collinear.
+func CircleFit(pairs []util.PairType, method int) (fit CircleFitType, err error) {
+	if len(pairs) < 3 {
+		return fit, errors.New("insufficient number of points to fit circle")
+	}
+	switch method {
+	case CircleKasa:
+		fit, err = circleKasa(pairs)
+	case CirclePratt, CircleTaubin:
+		fit, err = circleAlgebraic(pairs, method)
+	case CircleGeometric:
+		fit, err = circleAlgebraic(pairs, CircleTaubin)
+		if err == nil {
+			// Each point contributes its distance from the circle as a
+			// residual against an observation of zero
+			idxList := make([]float64, len(pairs))
+			zeroList := make([]float64, len(pairs))
+			for j := range idxList {
+				idxList[j] = float64(j)
+			}
+			model := func(x float64, params []float64) float64 {
+				pr := pairs[int(x)]
+				return math.Hypot(pr.X-params[0], pr.Y-params[1]) - params[2]
+			}
+			var cf CurveFitType
+			cf, err = CurveFit(model, idxList, zeroList, []float64{fit.X, fit.Y, fit.Radius})
+			if err == nil {
+				fit.X, fit.Y, fit.Radius = cf.Params[0], cf.Params[1], math.Abs(cf.Params[2])
+			}
+		}
+	default:
+		err = fmt.Errorf("unrecognized circle fit method %d", method)
+	}
+	if err == nil {
+		if math.IsNaN(fit.Radius) || math.IsInf(fit.Radius, 0) {
+			err = errors.New("points are collinear")
+		} else {
+			fit.RMS = circleRMS(pairs, fit)
+		}
+	}
+	return
+}
+
+// mat3Mul returns the product of the 3x3 matrices a and b.
+func mat3Mul(a, b [3][3]float64) (c [3][3]float64) {
+	for j := 0; j < 3; j++ {
+		for k := 0; k < 3; k++ {
+			for i := 0; i < 3; i++ {
+				c[j][k] += a[j][i] * b[i][k]
+			}
+		}
+	}
+	return
+}
+
+// cubicRoots returns the real roots of x^3 + a*x^2 + b*x + c.
+func cubicRoots(a, b, c float64) (list []float64) {
+	q := (a*a - 3*b) / 9
+	r := (2*a*a*a - 9*a*b + 27*c) / 54
+	if r*r < q*q*q {
+		t := math.Acos(r / math.Sqrt(q*q*q))
+		s := -2 * math.Sqrt(q)
+		list = []float64{s*math.Cos(t/3) - a/3, s*math.Cos((t+2*math.Pi)/3) - a/3,
+			s*math.Cos((t-2*math.Pi)/3) - a/3}
+	} else {
+		u := -math.Cbrt(r + math.Copysign(math.Sqrt(r*r-q*q*q), r))
+		v := 0.0
+		if u != 0 {
+			v = q / u
+		}
+		list = []float64{u + v - a/3}
+	}
+	return
+}
+
+// eigenVector3 returns a vector in the null space of m - val*I, where val is
+// an eigenvalue of the 3x3 matrix m, as the largest cross product of two of
+// its rows.
+func eigenVector3(m [3][3]float64, val float64) (vec [3]float64) {
+	for j := 0; j < 3; j++ {
+		m[j][j] -= val
+	}
+	var best float64
+	for _, rows := range [][2]int{{0, 1}, {0, 2}, {1, 2}} {
+		p, q := m[rows[0]], m[rows[1]]
+		v := [3]float64{p[1]*q[2] - p[2]*q[1], p[2]*q[0] - p[0]*q[2], p[0]*q[1] - p[1]*q[0]}
+		if n := v[0]*v[0] + v[1]*v[1] + v[2]*v[2]; n > best {
+			best = n
+			vec = v
+		}
+	}
+	return
+}
+
+// EllipseFit returns the center, semi-axes and rotation of the ellipse that
+// best fits the points specified by pairs. It uses the direct least squares
+// method of Fitzgibbon, Pilu and Fisher in the numerically stable form of
+// Halir and Flusser, which always yields an ellipse. The points are centered
+// and scaled before fitting. An error is returned if there are fewer than
+// five points or if no ellipse can be fitted to them.
+func EllipseFit(pairs []util.PairType) (fit EllipseFitType, err error) {
+	count := len(pairs)
+	if count < 5 {
+		return fit, errors.New("insufficient number of points to fit ellipse")
+	}
+	mo := moments(pairs)
+	scale := math.Sqrt((mo.xx + mo.yy) / 2)
+	if scale == 0 {
+		return fit, errors.New("points are coincident")
+	}
+	var s1, s2, s3 [3][3]float64
+	for _, pr := range pairs {
+		x := (pr.X - mo.mx) / scale
+		y := (pr.Y - mo.my) / scale
+		d1 := [3]float64{x * x, x * y, y * y}
+		d2 := [3]float64{x, y, 1}
+		for j := 0; j < 3; j++ {
+			for k := 0; k < 3; k++ {
+				s1[j][k] += d1[j] * d1[k]
+				s2[j][k] += d1[j] * d2[k]
+				s3[j][k] += d2[j] * d2[k]
+			}
+		}
+	}
+	// t = -inverse(s3) * transpose(s2)
+	s3List := make([][]float64, 3)
+	for j := range s3List {
+		s3List[j] = s3[j][:]
+	}
+	var inv [][]float64
+	inv, err = invert(s3List)
+	if err != nil {
+		return fit, errors.New("points are collinear")
+	}
+	var s3Inv, s2T [3][3]float64
+	for j := 0; j < 3; j++ {
+		for k := 0; k < 3; k++ {
+			s3Inv[j][k] = -inv[j][k]
+			s2T[j][k] = s2[k][j]
+		}
+	}
+	t := mat3Mul(s3Inv, s2T)
+	m := mat3Mul(s2, t)
+	for j := 0; j < 3; j++ {
+		for k := 0; k < 3; k++ {
+			m[j][k] += s1[j][k]
+		}
+	}
+	// Premultiply by the inverse of the constraint matrix
+	m = [3][3]float64{
+		{m[2][0] / 2, m[2][1] / 2, m[2][2] / 2},
+		{-m[1][0], -m[1][1], -m[1][2]},
+		{m[0][0] / 2, m[0][1] / 2, m[0][2] / 2}}
+	tr := m[0][0] + m[1][1] + m[2][2]
+	minors := m[0][0]*m[1][1] - m[0][1]*m[1][0] + m[0][0]*m[2][2] - m[0][2]*m[2][0] +
+		m[1][1]*m[2][2] - m[1][2]*m[2][1]
+	det := m[0][0]*(m[1][1]*m[2][2]-m[1][2]*m[2][1]) - m[0][1]*(m[1][0]*m[2][2]-m[1][2]*m[2][0]) +
+		m[0][2]*(m[1][0]*m[2][1]-m[1][1]*m[2][0])
+	var a1 [3]float64
+	found := false
+	for _, val := range cubicRoots(-tr, minors, -det) {
+		vec := eigenVector3(m, val)
+		if cond := 4*vec[0]*vec[2] - vec[1]*vec[1]; cond > 0 {
+			a1 = vec
+			found = true
+		}
+	}
+	if !found {
+		return fit, errors.New("no ellipse fits the points")
+	}
+	var a2 [3]float64
+	for j := 0; j < 3; j++ {
+		for k := 0; k < 3; k++ {
+			a2[j] += t[j][k] * a1[k]
+		}
+	}
+	a, b, c := a1[0], a1[1], a1[2]
+	d, e, f := a2[0], a2[1], a2[2]
+	den := b*b - 4*a*c
+	x0 := (2*c*d - b*e) / den
+	y0 := (2*a*e - b*d) / den
+	f0 := a*x0*x0 + b*x0*y0 + c*y0*y0 + d*x0 + e*y0 + f
+	h := b / 2
+	mid := (a + c) / 2
+	rad := math.Hypot((a-c)/2, h)
+	valMin, valMax := mid-rad, mid+rad
+	if -f0/valMin <= 0 || -f0/valMax <= 0 {
+		return fit, errors.New("no ellipse fits the points")
+	}
+	// The major axis corresponds to the smaller eigenvalue of the quadratic
+	// form
+	if h != 0 {
+		fit.Angle = math.Atan2(valMin-a, h)
+	} else if a > c {
+		fit.Angle = math.Pi / 2
+	}
+	if fit.Angle > math.Pi/2 {
+		fit.Angle -= math.Pi
+	} else if fit.Angle <= -math.Pi/2 {
+		fit.Angle += math.Pi
+	}
+	fit.SemiMajor = math.Sqrt(-f0/valMin) * scale
+	fit.SemiMinor = math.Sqrt(-f0/valMax) * scale
+	fit.X = x0*scale + mo.mx
+	fit.Y = y0*scale + mo.my
+	// Sampson distance approximates the geometric distance of each point
+	list := make([]float64, count)
+	for j, pr := range pairs {
+		x := (pr.X - mo.mx) / scale
+		y := (pr.Y - mo.my) / scale
+		q := a*x*x + b*x*y + c*y*y + d*x + e*y + f
+		gx := 2*a*x + b*y + d
+		gy := b*x + 2*c*y + e
+		if g := math.Hypot(gx, gy); g > 0 {
+			list[j] = q / g * scale
+		}
+	}
+	fit.RMS = util.RootMeanSquare(list)
+	return
+}
@@ -0,0 +1,284 @@
+package regression
+
+import (
+	"errors"
+	"math"
+)
+
+const (
+	cnCurveIter   = 200   // maximum number of Levenberg-Marquardt iterations
+	cnCurveTol    = 1e-12 // relative change in sum of squares that ends the search
+	cnLambdaInit  = 1e-3  // initial Levenberg-Marquardt damping
+	cnLambdaLimit = 1e16  // damping beyond which no further progress is possible
+)
+
+// ModelFunc is the signature of a model passed to CurveFit(). It returns the
+// value of the model at x for the parameters specified by params.
+type ModelFunc func(x float64, params []float64) float64
+
+// CurveFitType groups together the fitted parameters of a model, their
+// standard errors, the residuals (observed minus fitted values), the
+// coefficient of determination, and the root-mean-square average deviation
+// of a nonlinear regression fit. StdErr is nil if there are no more points
+// than parameters.
+type CurveFitType struct {
+	Params    []float64
+	StdErr    []float64
+	Residuals []float64
+	RSquared  float64
+	RMS       float64
+}
+
+// ExponentialModel returns params[0] * exp(params[1] * x).
+func ExponentialModel(x float64, params []float64) float64 {
+	return params[0] * math.Exp(params[1]*x)
+}
+
+// LogarithmicModel returns params[0] + params[1] * ln(x).
+func LogarithmicModel(x float64, params []float64) float64 {
+	return params[0] + params[1]*math.Log(x)
+}
+
+// PowerModel returns params[0] * x^params[1].
+func PowerModel(x float64, params []float64) float64 {
+	return params[0] * math.Pow(x, params[1])
+}
+
+// GaussianModel returns the bell curve with height params[0], center
+// params[1] and standard deviation params[2].
+func GaussianModel(x float64, params []float64) float64 {
+	d := (x - params[1]) / params[2]
+	return params[0] * math.Exp(-d*d/2)
+}
+
+// curveSum returns the sum of the squared differences between yList and
+// model evaluated at xList with the parameters specified by params.
+func curveSum(model ModelFunc, xList, yList, params []float64) (sum float64) {
+	for j, x := range xList {
+		diff := yList[j] - model(x, params)
+		sum += diff * diff
+	}
+	return
+}
+
+// curveJacobian returns the partial derivatives of model with respect to each
+// of the parameters specified by params, evaluated at each value of xList, by
+// forward differences. estList holds the model values at params.
+func curveJacobian(model ModelFunc, xList, params, estList []float64) (jac [][]float64) {
+	count := len(params)
+	jac = make([][]float64, len(xList))
+	for j := range jac {
+		jac[j] = make([]float64, count)
+	}
+	probe := append([]float64(nil), params...)
+	for k := range params {
+		h := math.Sqrt(2.2e-16) * math.Max(math.Abs(params[k]), 1)
+		probe[k] = params[k] + h
+		for j, x := range xList {
+			jac[j][k] = (model(x, probe) - estList[j]) / h
+		}
+		probe[k] = params[k]
+	}
+	return
+}
+
+// invert returns the inverse of the square matrix specified by m using
+// Gauss-Jordan elimination with partial pivoting. The contents of m are
+// overwritten.
+func invert(m [][]float64) (inv [][]float64, err error) {
+	count := len(m)
+	inv = make([][]float64, count)
+	for j := range inv {
+		inv[j] = make([]float64, count)
+		inv[j][j] = 1
+	}
+	for k := 0; k < count; k++ {
+		pivot := k
+		for j := k + 1; j < count; j++ {
+			if math.Abs(m[j][k]) > math.Abs(m[pivot][k]) {
+				pivot = j
+			}
+		}
+		if m[pivot][k] == 0 {
+			return nil, errors.New("matrix is singular")
+		}
+		m[k], m[pivot] = m[pivot], m[k]
+		inv[k], inv[pivot] = inv[pivot], inv[k]
+		div := m[k][k]
+		for c := 0; c < count; c++ {
+			m[k][c] /= div
+			inv[k][c] /= div
+		}
+		for j := 0; j < count; j++ {
+			if j != k && m[j][k] != 0 {
+				f := m[j][k]
+				for c := 0; c < count; c++ {
+					m[j][c] -= f * m[k][c]
+					inv[j][c] -= f * inv[k][c]
+				}
+			}
+		}
+	}
+	return
+}
+
+// curveCheck returns an error if the points specified by xList and yList
+// cannot be used to fit a model with the parameters specified by init.
+func curveCheck(xList, yList, init []float64) (err error) {
+	if len(xList) != len(yList) {
+		err = errors.New("x and y lists differ in length")
+	} else if len(init) == 0 {
+		err = errors.New("model requires at least one parameter")
+	} else if len(xList) < len(init) {
+		err = errors.New("insufficient number of points for model parameters")
+	}
+	return
+}
+
+// curveFinish assigns params to fit and calculates the residuals, r-squared
+// value, RMS value and parameter standard errors.
+func curveFinish(model ModelFunc, xList, yList, params []float64) (fit CurveFitType) {
+	var ssRes float64
+	count := len(xList)
+	fit.Params = params
+	estList := make([]float64, count)
+	fit.Residuals = make([]float64, count)
+	for j, x := range xList {
+		estList[j] = model(x, params)
+		fit.Residuals[j] = yList[j] - estList[j]
+		ssRes += fit.Residuals[j] * fit.Residuals[j]
+	}
+	fit.RSquared, fit.RMS = fitStats(yList, estList)
+	dof := count - len(params)
+	if dof > 0 {
+		// Covariance is s^2 * inverse(J'J)
+		jac := curveJacobian(model, xList, params, estList)
+		jtj := make([][]float64, len(params))
+		for a := range jtj {
+			jtj[a] = make([]float64, len(params))
+			for b := range jtj[a] {
+				for j := range jac {
+					jtj[a][b] += jac[j][a] * jac[j][b]
+				}
+			}
+		}
+		cov, err := invert(jtj)
+		if err == nil {
+			s2 := ssRes / float64(dof)
+			fit.StdErr = make([]float64, len(params))
+			for k := range params {
+				fit.StdErr[k] = math.Sqrt(math.Abs(s2 * cov[k][k]))
+			}
+		}
+	}
+	return
+}
+
+// CurveFit uses the Levenberg-Marquardt method to find the parameters of
+// model that minimize the sum of the squared differences between the model
+// and the points specified by xList and yList. The number of parameters and
+// their starting values are specified by init; an estimate that is roughly
+// right helps the search avoid suboptimal local minima. Derivatives are
+// estimated numerically. Ready-made models include ExponentialModel,
+// LogarithmicModel, PowerModel and GaussianModel. An error is returned if the
+// search does not converge within 200 iterations.
+func CurveFit(model ModelFunc, xList, yList, init []float64) (fit CurveFitType, err error) {
+	err = curveCheck(xList, yList, init)
+	if err != nil {
+		return
+	}
+	count := len(xList)
+	size := len(init)
+	params := append([]float64(nil), init...)
+	trial := make([]float64, size)
+	estList := make([]float64, count)
+	sum := curveSum(model, xList, yList, params)
+	lambda := cnLambdaInit
+	iter := 0
+	for ; iter < cnCurveIter && lambda < cnLambdaLimit; iter++ {
+		for j, x := range xList {
+			estList[j] = model(x, params)
+		}
+		jac := curveJacobian(model, xList, params, estList)
+		// Scale damping by the column norms so that it is invariant to the
+		// units of each parameter
+		scale := make([]float64, size)
+		for k := range scale {
+			for j := range jac {
+				scale[k] += jac[j][k] * jac[j][k]
+			}
+			if scale[k] == 0 {
+				scale[k] = 1
+			}
+		}
+		improved := false
+		for !improved && lambda < cnLambdaLimit {
+			// The damped step solves [J; sqrt(lambda) D] * step = [r; 0]
+			a := make([][]float64, count+size)
+			b := make([]float64, count+size)
+			for j := range jac {
+				a[j] = append([]float64(nil), jac[j]...)
+				b[j] = yList[j] - estList[j]
+			}
+			for k := 0; k < size; k++ {
+				a[count+k] = make([]float64, size)
+				a[count+k][k] = math.Sqrt(lambda * scale[k])
+			}
+			step, stepErr := leastSquares(a, b)
+			if stepErr == nil {
+				for k := range params {
+					trial[k] = params[k] + step[k]
+				}
+				trialSum := curveSum(model, xList, yList, trial)
+				if trialSum < sum {
+					improved = true
+					change := (sum - trialSum) / math.Max(sum, math.SmallestNonzeroFloat64)
+					copy(params, trial)
+					sum = trialSum
+					lambda /= 10
+					if change < cnCurveTol {
+						lambda = cnLambdaLimit
+					}
+				}
+			}
+			if !improved {
+				lambda *= 10
+			}
+		}
+	}
+	// The search ends with lambda at its limit when the sum of squares stops
+	// improving; otherwise the iteration limit was reached
+	if math.IsNaN(sum) || math.IsInf(sum, 0) || (iter == cnCurveIter && lambda < cnLambdaLimit) {
+		err = errors.New("curve fit did not converge")
+	} else {
+		fit = curveFinish(model, xList, yList, params)
+	}
+	return
+}
+
+// CurveFitSimplex is like CurveFit() but searches for the parameters with
+// the downhill simplex (Nelder-Mead) method, which does not need derivatives
+// and so can handle models that are not smooth. It typically needs many more
+// evaluations of model than CurveFit(). The initial simplex size is a tenth
+// of the largest initial parameter magnitude, or 0.1 if all are zero.
+func CurveFitSimplex(model ModelFunc, xList, yList, init []float64) (fit CurveFitType, err error) {
+	err = curveCheck(xList, yList, init)
+	if err == nil {
+		var params []float64
+		length := 0.0
+		for _, p := range init {
+			length = math.Max(length, math.Abs(p))
+		}
+		if length == 0 {
+			length = 1
+		}
+		sumFnc := func(params []float64) float64 {
+			return curveSum(model, xList, yList, params)
+		}
+		params, err = DownhillSimplex(sumFnc, init, length/10, 1.25)
+		if err == nil {
+			fit = curveFinish(model, xList, yList, params)
+		}
+	}
+	return
+}
@@ -6,7 +6,6 @@ import (
 	"math"
 
 	"github.com/jung-kurt/etc/go/util"
-	"gonum.org/v1/gonum/optimize"
 	"gonum.org/v1/gonum/stat"
 )
 
@@ -45,16 +44,12 @@ func (fit LinearFitType) String() string {
 // number of elements as well. Two parameters can be adjusted to avoid
 // converging on suboptimal local minima: length specifies the simplex size,
 // and expansion (some value greater than 1) specifies the multiplier used when
-// expanding the simplex.
+// expanding the simplex. See DownhillSimplexOptions() for more control over
+// the search.
 func DownhillSimplex(fnc func(x []float64) float64, init []float64, length, expansion float64) (res []float64, err error) {
-	var prb optimize.Problem
-	var r *optimize.Result
+	var r SimplexResultType
 
-	prb.Func = fnc
-	r, err = optimize.Local(prb, init, nil, &optimize.NelderMead{
-		SimplexSize: length,
-		Expansion:   expansion, // 1.25,
-	})
+	r, err = DownhillSimplexOptions(fnc, init, SimplexOptionsType{Length: length, Expansion: expansion})
 	if err == nil {
 		res = r.X
 	}
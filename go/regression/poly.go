@@ -0,0 +1,224 @@
+package regression
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/jung-kurt/etc/go/util"
+)
+
+// PolynomialFitType groups together the coefficients, coefficient of
+// determination, and root-mean-square average deviation of a polynomial
+// regression curve. Coef[j] is the coefficient of x raised to the power j, so
+// the degree of the polynomial is len(Coef) - 1. The coefficients are meant
+// for display; for higher degree fits they can lose much of their precision
+// to cancellation, so Eval() uses the well conditioned form of the fit.
+type PolynomialFitType struct {
+	Coef     []float64
+	RSquared float64
+	RMS      float64
+	mid      float64   // center of the x range
+	half     float64   // half width of the x range
+	scaled   []float64 // coefficients of powers of (x - mid) / half
+}
+
+// Eval returns the value of the fitted polynomial at x.
+func (fit PolynomialFitType) Eval(x float64) (y float64) {
+	if fit.scaled == nil {
+		for j := len(fit.Coef) - 1; j >= 0; j-- {
+			y = y*x + fit.Coef[j]
+		}
+	} else {
+		t := (x - fit.mid) / fit.half
+		for j := len(fit.scaled) - 1; j >= 0; j-- {
+			y = y*t + fit.scaled[j]
+		}
+	}
+	return
+}
+
+// String implements the fmt Stringer interface.
+func (fit PolynomialFitType) String() string {
+	var buf strings.Builder
+	buf.WriteString("y(x) =")
+	for j := len(fit.Coef) - 1; j >= 0; j-- {
+		c := fit.Coef[j]
+		op := "+"
+		if c < 0 {
+			c = -c
+			op = "-"
+		}
+		if j == len(fit.Coef)-1 {
+			if op == "-" {
+				buf.WriteString(" -")
+			}
+		} else {
+			fmt.Fprintf(&buf, " %s", op)
+		}
+		fmt.Fprintf(&buf, " %s", f3(c))
+		switch j {
+		case 0:
+		case 1:
+			buf.WriteString(" * x")
+		default:
+			fmt.Fprintf(&buf, " * x^%d", j)
+		}
+	}
+	fmt.Fprintf(&buf, " (r squared %s, RMS %s)", f3(fit.RSquared), f3(fit.RMS))
+	return buf.String()
+}
+
+// fitStats returns the coefficient of determination and the root-mean-square
+// deviation of the estimates specified by estList with respect to the
+// observations specified by yList.
+func fitStats(yList, estList []float64) (rSquared, rms float64) {
+	var ssRes, ssTot float64
+	count := len(yList)
+	if count > 0 {
+		mean := util.ArithmeticMean(yList)
+		for j, y := range yList {
+			diff := y - estList[j]
+			ssRes += diff * diff
+			diff = y - mean
+			ssTot += diff * diff
+		}
+		rms = math.Sqrt(ssRes / float64(count))
+		if ssTot > 0 {
+			rSquared = 1 - ssRes/ssTot
+		} else if ssRes == 0 {
+			rSquared = 1
+		}
+	}
+	return
+}
+
+// leastSquares returns the vector x that minimizes the Euclidean norm of
+// a*x - b, where a is a matrix of len(b) rows and len(a[0]) columns. The
+// Householder QR decomposition is used rather than the normal equations to
+// avoid squaring the condition number of a. The contents of a and b are
+// overwritten. An error is returned if a has fewer rows than columns or if
+// its columns are linearly dependent.
+func leastSquares(a [][]float64, b []float64) (x []float64, err error) {
+	rows := len(b)
+	if rows == 0 || len(a) != rows {
+		return nil, errors.New("least squares system is empty or inconsistent")
+	}
+	cols := len(a[0])
+	if rows < cols {
+		return nil, errors.New("insufficient number of points for least squares fit")
+	}
+	var maxDiag float64
+	for k := 0; k < cols; k++ {
+		var norm float64
+		for j := k; j < rows; j++ {
+			norm = math.Hypot(norm, a[j][k])
+		}
+		if norm > maxDiag {
+			maxDiag = norm
+		}
+		if norm <= maxDiag*float64(rows)*1e-14 {
+			return nil, errors.New("least squares system is singular")
+		}
+		if a[k][k] > 0 {
+			norm = -norm
+		}
+		// Householder vector v is stored in column k, scaled so that v[k] is
+		// a[k][k] - norm
+		a[k][k] -= norm
+		vv := -a[k][k] * norm
+		for c := k + 1; c < cols; c++ {
+			var dot float64
+			for j := k; j < rows; j++ {
+				dot += a[j][k] * a[j][c]
+			}
+			dot /= vv
+			for j := k; j < rows; j++ {
+				a[j][c] -= dot * a[j][k]
+			}
+		}
+		var dot float64
+		for j := k; j < rows; j++ {
+			dot += a[j][k] * b[j]
+		}
+		dot /= vv
+		for j := k; j < rows; j++ {
+			b[j] -= dot * a[j][k]
+		}
+		a[k][k] = norm
+	}
+	x = make([]float64, cols)
+	for k := cols - 1; k >= 0; k-- {
+		sum := b[k]
+		for c := k + 1; c < cols; c++ {
+			sum -= a[k][c] * x[c]
+		}
+		x[k] = sum / a[k][k]
+	}
+	return
+}
+
+// PolynomialFit returns the coefficients, r-squared value, and RMS value for
+// the least squares fit of a polynomial of the specified degree to the points
+// specified by xList and yList. To keep higher degree fits well conditioned, x
+// values are mapped to the interval [-1, 1] and the system is solved with QR
+// decomposition. Eval(), and the r-squared and RMS values, work with the
+// mapped values; the returned coefficients apply to the original x values. An
+// error is returned if the lists differ in length or if there are fewer
+// distinct x values than degree + 1.
+func PolynomialFit(xList, yList []float64, degree int) (fit PolynomialFitType, err error) {
+	count := len(xList)
+	if degree < 0 {
+		err = errors.New("polynomial degree must not be negative")
+	} else if count != len(yList) {
+		err = errors.New("x and y lists differ in length")
+	} else if count < degree+1 {
+		err = errors.New("insufficient number of points for polynomial degree")
+	}
+	if err != nil {
+		return
+	}
+	var rng util.RangeType
+	for j, x := range xList {
+		rng.Set(x, j == 0)
+	}
+	mid := (rng.Min + rng.Max) / 2
+	half := (rng.Max - rng.Min) / 2
+	if half == 0 {
+		half = 1
+	}
+	a := make([][]float64, count)
+	b := make([]float64, count)
+	for j, x := range xList {
+		t := (x - mid) / half
+		a[j] = make([]float64, degree+1)
+		p := 1.0
+		for k := range a[j] {
+			a[j][k] = p
+			p *= t
+		}
+		b[j] = yList[j]
+	}
+	fit.scaled, err = leastSquares(a, b)
+	if err == nil {
+		fit.mid = mid
+		fit.half = half
+		// Expand sum of c[k] * ((x - mid) / half)^k into powers of x
+		fit.Coef = make([]float64, degree+1)
+		for k, ck := range fit.scaled {
+			scale := ck / math.Pow(half, float64(k))
+			binom := 1.0
+			for j := 0; j <= k; j++ {
+				fit.Coef[j] += scale * binom * math.Pow(-mid, float64(k-j))
+				binom = binom * float64(k-j) / float64(j+1)
+			}
+		}
+		estList := make([]float64, count)
+		for j, x := range xList {
+			estList[j] = fit.Eval(x)
+		}
+		fit.RSquared, fit.RMS = fitStats(yList, estList)
+	}
+	return
+}
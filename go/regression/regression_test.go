@@ -18,6 +18,7 @@ package regression_test
 
 import (
 	"fmt"
+	"math"
 	"testing"
 
 	"github.com/jung-kurt/etc/go/regression"
@@ -50,50 +51,255 @@ func ExampleLinearFit() {
 	// y(x) = 2.06 * x - 9.85 (r squared 0.987, RMS 0.266)
 }
 
+// Error should be returned when PolynomialFit has too few points for the
+// requested degree.
+func Test02(t *testing.T) {
+	_, err := regression.PolynomialFit([]float64{1, 2, 3}, []float64{1, 4, 9}, 3)
+	if err == nil {
+		t.Fatalf("expecting error with insufficient points")
+	}
+	_, err = regression.PolynomialFit([]float64{1, 1, 2, 2}, []float64{1, 1, 4, 4}, 2)
+	if err == nil {
+		t.Fatalf("expecting error with insufficient distinct x values")
+	}
+}
+
+// Error should be returned when robust line fits are given unusable points.
+func Test03(t *testing.T) {
+	_, _, err := regression.LinearFitTheilSen([]float64{2, 2, 2}, []float64{1, 2, 3})
+	if err == nil {
+		t.Fatalf("expecting error with identical x values")
+	}
+	_, _, err = regression.LinearFitRANSAC([]float64{1, 2, 3}, []float64{1, 2, 3}, 0, 0)
+	if err == nil {
+		t.Fatalf("expecting error with zero threshold")
+	}
+	_, _, err = regression.LinearFitHuber([]float64{1}, []float64{1}, 0)
+	if err == nil {
+		t.Fatalf("expecting error with single point")
+	}
+	_, err = regression.LinearFitWeighted([]float64{1, 2, 3}, []float64{1, 2, 3}, nil)
+	if err == nil {
+		t.Fatalf("expecting error with missing weights")
+	}
+	_, err = regression.LinearFitWeighted([]float64{1, 2, 3}, []float64{1, 2, 3}, []float64{1, -1, 1})
+	if err == nil {
+		t.Fatalf("expecting error with negative weight")
+	}
+}
+
+// Error should be returned when CurveFit has fewer points than parameters or
+// does not converge.
+func Test04(t *testing.T) {
+	_, err := regression.CurveFit(regression.GaussianModel, []float64{1, 2}, []float64{1, 2}, []float64{1, 1, 1})
+	if err == nil {
+		t.Fatalf("expecting error with insufficient points")
+	}
+	// The sum of squares of this model approaches zero without reaching it
+	model := func(x float64, params []float64) float64 {
+		return math.Exp(-params[0])
+	}
+	_, err = regression.CurveFit(model, []float64{1}, []float64{0}, []float64{0})
+	if err == nil {
+		t.Fatalf("expecting error when search does not converge")
+	}
+}
+
+// Error should be returned when circle and ellipse fits are given collinear
+// points.
+func Test05(t *testing.T) {
+	pairs := []util.PairType{{X: 1, Y: 1}, {X: 2, Y: 2}, {X: 3, Y: 3}, {X: 4, Y: 4}, {X: 5, Y: 5}}
+	for method := regression.CircleKasa; method <= regression.CircleGeometric; method++ {
+		_, err := regression.CircleFit(pairs, method)
+		if err == nil {
+			t.Fatalf("expecting error from method %d with collinear points", method)
+		}
+	}
+	_, err := regression.EllipseFit(pairs)
+	if err == nil {
+		t.Fatalf("expecting error with collinear points")
+	}
+}
+
+// ExamplePolynomialFit demonstrates fitting a quadratic curve to observation
+// points
+func ExamplePolynomialFit() {
+	var (
+		xList = []float64{1000, 1001, 1002, 1003, 1004, 1005, 1006}
+		yList = []float64{2.1, 0.9, 2.2, 6.8, 15.1, 27.3, 42.8}
+	)
+
+	fit, err := regression.PolynomialFit(xList, yList, 2)
+	if err == nil {
+		fmt.Printf("%s\n", fit)
+		fmt.Printf("y(1007) = %s\n", util.Float64ToStrSig(fit.Eval(1007), ".", ",", 3, 3))
+	} else {
+		fmt.Printf("polynomial fit error: %s\n", err)
+	}
+	// Output:
+	// y(x) = 1.73 * x^2 - 3,470 * x + 1,730,000 (r squared 1.00, RMS 0.258)
+	// y(1007) = 61.5
+}
+
+// ExampleLinearFitHuber demonstrates the robust line fits on observation
+// points that include an outlier
+func ExampleLinearFitHuber() {
+	var (
+		xList = []float64{1, 2, 3, 4, 5, 6, 7, 8}
+		yList = []float64{3.1, 4.9, 7.2, 8.8, 11.1, 40.0, 15.2, 16.9}
+	)
+
+	show := func(nameStr string, le regression.LinearFitType, inliers []bool, err error) {
+		if err == nil {
+			fmt.Printf("%-10s %s %v\n", nameStr, le, inliers)
+		} else {
+			fmt.Printf("%-10s error: %s\n", nameStr, err)
+		}
+	}
+	le := regression.LinearFit(xList, yList)
+	fmt.Printf("%-10s %s\n", "Ordinary", le)
+	le, err := regression.LinearFitWeighted(xList, yList, []float64{1, 1, 1, 1, 1, 0, 1, 1})
+	if err == nil {
+		fmt.Printf("%-10s %s\n", "Weighted", le)
+	} else {
+		fmt.Printf("%-10s error: %s\n", "Weighted", err)
+	}
+	le, inliers, err := regression.LinearFitTheilSen(xList, yList)
+	show("Theil-Sen", le, inliers, err)
+	le, inliers, err = regression.LinearFitRANSAC(xList, yList, 1, 0)
+	show("RANSAC", le, inliers, err)
+	le, inliers, err = regression.LinearFitHuber(xList, yList, 0)
+	show("Huber", le, inliers, err)
+	// Output:
+	// Ordinary   y(x) = 2.96 * x + 0.0714 (r squared 0.381, RMS 8.64)
+	// Weighted   y(x) = 2.00 * x + 1.03 (r squared 0.999, RMS 0.148)
+	// Theil-Sen  y(x) = 2.01 * x + 1.08 (r squared 0.999, RMS 0.171) [true true true true true false true true]
+	// RANSAC     y(x) = 2.00 * x + 1.03 (r squared 0.999, RMS 0.148) [true true true true true false true true]
+	// Huber      y(x) = 2.01 * x + 1.02 (r squared 0.999, RMS 0.157) [true true true true true false true true]
+}
+
+// ExampleCurveFit demonstrates fitting nonlinear models to observation points
+func ExampleCurveFit() {
+	var (
+		xList = []float64{0, 1, 2, 3, 4, 5, 6, 7}
+		yList = []float64{2.4, 3.5, 4.5, 6.1, 8.2, 11.3, 15.0, 20.5}
+		gList = []float64{0.3, 1.4, 4.2, 7.6, 8.1, 5.4, 2.3, 0.6}
+	)
+
+	f3 := func(val float64) string {
+		return util.Float64ToStrSig(val, ".", ",", 3, 3)
+	}
+	show := func(nameStr string, fit regression.CurveFitType, err error) {
+		if err == nil {
+			fmt.Printf("%s:", nameStr)
+			for j := range fit.Params {
+				fmt.Printf(" %s (%s)", f3(fit.Params[j]), f3(fit.StdErr[j]))
+			}
+			fmt.Printf(", r squared %s, RMS %s\n", f3(fit.RSquared), f3(fit.RMS))
+		} else {
+			fmt.Printf("%s error: %s\n", nameStr, err)
+		}
+	}
+	fit, err := regression.CurveFit(regression.ExponentialModel, xList, yList, []float64{1, 0.1})
+	show("Exponential", fit, err)
+	fit, err = regression.CurveFitSimplex(regression.ExponentialModel, xList, yList, []float64{1, 0.1})
+	show("Exponential (simplex)", fit, err)
+	fit, err = regression.CurveFit(regression.GaussianModel, xList, gList, []float64{5, 3, 1})
+	show("Gaussian", fit, err)
+	// Output:
+	// Exponential: 2.47 (0.0355) 0.302 (0.00238), r squared 1.00, RMS 0.0911
+	// Exponential (simplex): 2.47 (0.0355) 0.302 (0.00238), r squared 1.00, RMS 0.0911
+	// Gaussian: 8.38 (0.0566) 3.67 (0.0112) 1.43 (0.0112), r squared 1.00, RMS 0.0581
+}
+
+// arcPairs are observed points along the bottom of a circle
+var arcPairs = []util.PairType{
+	{X: 376.25, Y: 519.21},
+	{X: 387.5, Y: 503.0749999999998},
+	{X: 398.75, Y: 487.1149999999998},
+	{X: 410, Y: 471.1199999999999},
+	{X: 421.25, Y: 459.57000000000016},
+	{X: 432.5, Y: 451.55499999999984},
+	{X: 443.75, Y: 444.9749999999999},
+	{X: 455, Y: 436.6100000000001},
+	{X: 466.25, Y: 425.27},
+	{X: 477.5, Y: 419.17999999999984},
+	{X: 488.75, Y: 416.30999999999995},
+	{X: 500, Y: 412.91499999999996},
+	{X: 511.25, Y: 409.9050000000002},
+	{X: 522.5, Y: 407.98},
+	{X: 533.75, Y: 407.03499999999985},
+	{X: 545, Y: 405.91499999999996},
+	{X: 556.25, Y: 405.32000000000016},
+	{X: 567.5, Y: 404.9000000000001},
+	{X: 578.75, Y: 406.5799999999999},
+	{X: 590, Y: 409.625},
+	{X: 601.25, Y: 409.3449999999998},
+	{X: 612.5, Y: 413.05499999999984},
+	{X: 623.75, Y: 418.30499999999984},
+	{X: 635, Y: 423.0300000000002},
+	{X: 646.25, Y: 427.05499999999984},
+	{X: 657.5, Y: 431.9200000000001},
+	{X: 668.75, Y: 443.4000000000001},
+	{X: 680, Y: 447.80999999999995},
+	{X: 691.25, Y: 452.42999999999984},
+	{X: 702.5, Y: 463.4200000000001},
+	{X: 713.75, Y: 484.5250000000001},
+	{X: 725, Y: 498.98},
+	{X: 736.25, Y: 516.3049999999998},
+}
+
+// ExampleDownhillSimplexOptions demonstrates a bounded search with random
+// restarts for the lowest point of a function with many local minima
+func ExampleDownhillSimplexOptions() {
+	fnc := func(x []float64) float64 {
+		// Rastrigin function, lowest at the origin
+		val := 20.0
+		for _, v := range x {
+			val += v*v - 10*math.Cos(2*math.Pi*v)
+		}
+		return val
+	}
+	r2 := func(val float64) float64 {
+		// Adding zero avoids printing negative zero
+		return math.Round(val*100)/100 + 0
+	}
+	show := func(nameStr string, res regression.SimplexResultType, err error) {
+		if err == nil {
+			fmt.Printf("%s: [%.2f, %.2f] f %.2f, %d searches, %s\n", nameStr, r2(res.X[0]), r2(res.X[1]),
+				r2(res.F), res.Searches, res.Status)
+		} else {
+			fmt.Printf("%s error: %s\n", nameStr, err)
+		}
+	}
+	init := []float64{3.2, -2.1}
+	res, err := regression.DownhillSimplexOptions(fnc, init, regression.SimplexOptionsType{Length: 0.5})
+	show("single", res, err)
+	res, err = regression.DownhillSimplexOptions(fnc, init, regression.SimplexOptionsType{Length: 0.5,
+		Lower: []float64{-4, -4}, Upper: []float64{4, 4}, Restarts: 100, RepeatTolerance: 1e-9})
+	show("restarts", res, err)
+	res, err = regression.DownhillSimplexOptions(fnc, init, regression.SimplexOptionsType{Length: 0.5,
+		Lower: []float64{3.5, math.Inf(-1)}, Upper: []float64{math.Inf(1), 0}})
+	show("bounded", res, err)
+	res, err = regression.DownhillSimplexOptions(fnc, init, regression.SimplexOptionsType{Length: 0.5,
+		Iterations: 5})
+	fmt.Printf("limited: %d iterations, %s\n", res.Iterations, res.Status)
+	// Output:
+	// single: [2.98, -1.99] f 12.93, 1 searches, FunctionConvergence
+	// restarts: [0.00, 0.00] f 0.00, 202 searches, FunctionConvergence
+	// bounded: [3.98, -1.99] f 19.90, 1 searches, FunctionConvergence
+	// limited: 5 iterations, IterationLimit
+}
+
 func ExampleCenter() {
 	var err error
 	var x, y float64
-	var pairs = []util.PairType{
-		{X: 376.25, Y: 519.21},
-		{X: 387.5, Y: 503.0749999999998},
-		{X: 398.75, Y: 487.1149999999998},
-		{X: 410, Y: 471.1199999999999},
-		{X: 421.25, Y: 459.57000000000016},
-		{X: 432.5, Y: 451.55499999999984},
-		{X: 443.75, Y: 444.9749999999999},
-		{X: 455, Y: 436.6100000000001},
-		{X: 466.25, Y: 425.27},
-		{X: 477.5, Y: 419.17999999999984},
-		{X: 488.75, Y: 416.30999999999995},
-		{X: 500, Y: 412.91499999999996},
-		{X: 511.25, Y: 409.9050000000002},
-		{X: 522.5, Y: 407.98},
-		{X: 533.75, Y: 407.03499999999985},
-		{X: 545, Y: 405.91499999999996},
-		{X: 556.25, Y: 405.32000000000016},
-		{X: 567.5, Y: 404.9000000000001},
-		{X: 578.75, Y: 406.5799999999999},
-		{X: 590, Y: 409.625},
-		{X: 601.25, Y: 409.3449999999998},
-		{X: 612.5, Y: 413.05499999999984},
-		{X: 623.75, Y: 418.30499999999984},
-		{X: 635, Y: 423.0300000000002},
-		{X: 646.25, Y: 427.05499999999984},
-		{X: 657.5, Y: 431.9200000000001},
-		{X: 668.75, Y: 443.4000000000001},
-		{X: 680, Y: 447.80999999999995},
-		{X: 691.25, Y: 452.42999999999984},
-		{X: 702.5, Y: 463.4200000000001},
-		{X: 713.75, Y: 484.5250000000001},
-		{X: 725, Y: 498.98},
-		{X: 736.25, Y: 516.3049999999998},
-	}
 
 	f3 := func(val float64) string {
 		return util.Float64ToStrSig(val, ".", ",", 3, 3)
 	}
 
-	x, y, err = regression.Center(pairs, 200)
+	x, y, err = regression.Center(arcPairs, 200)
 	if err == nil {
 		fmt.Printf("downhill simplex: center [%s, %s]", f3(x), f3(y))
 	} else {
@@ -102,3 +308,84 @@ func ExampleCenter() {
 	// Output:
 	// downhill simplex: center [554, 263]
 }
+
+// ExampleCircleFit demonstrates fitting a circle of unknown radius and an
+// ellipse to observation points
+func ExampleCircleFit() {
+	for _, method := range []int{regression.CircleKasa, regression.CirclePratt,
+		regression.CircleTaubin, regression.CircleGeometric} {
+		fit, err := regression.CircleFit(arcPairs, method)
+		if err == nil {
+			fmt.Printf("circle: %s\n", fit)
+		} else {
+			fmt.Printf("circle error: %s\n", err)
+		}
+	}
+	var pairs []util.PairType
+	for j := 0; j < 24; j++ {
+		t := float64(j) * math.Pi / 12
+		// Ellipse with semi-axes 30 and 10 rotated by 30 degrees, plus a
+		// small perturbation
+		x := 30*math.Cos(t) + 0.2*math.Sin(7*t)
+		y := 10*math.Sin(t) + 0.2*math.Cos(5*t)
+		pairs = append(pairs, util.PairType{X: 100 + x*math.Cos(math.Pi/6) - y*math.Sin(math.Pi/6),
+			Y: 50 + x*math.Sin(math.Pi/6) + y*math.Cos(math.Pi/6)})
+	}
+	fit, err := regression.EllipseFit(pairs)
+	if err == nil {
+		fmt.Printf("ellipse: %s\n", fit)
+	} else {
+		fmt.Printf("ellipse error: %s\n", err)
+	}
+	// Output:
+	// circle: center [558, 603], radius 198 (RMS 1.62)
+	// circle: center [558, 604], radius 198 (RMS 1.62)
+	// circle: center [558, 604], radius 198 (RMS 1.62)
+	// circle: center [558, 604], radius 198 (RMS 1.62)
+	// ellipse: center [100, 50.0], axes 30.0 and 10.0, angle 30.0° (RMS 0.164)
+}
+
+// High degree polynomial fits of points far from the origin should remain
+// accurate.
+func Test06(t *testing.T) {
+	var xList, yList []float64
+	for j := 0; j <= 40; j++ {
+		x := 1000 + float64(j)/4
+		xList = append(xList, x)
+		yList = append(yList, math.Sin((x-1000)/3))
+	}
+	for degree := 8; degree <= 10; degree++ {
+		fit, err := regression.PolynomialFit(xList, yList, degree)
+		if err != nil {
+			t.Fatalf("unexpected error with degree %d: %s", degree, err)
+		}
+		if fit.RSquared < 0.9999 || fit.RMS > 0.001 {
+			t.Fatalf("poor fit with degree %d: r squared %g, RMS %g", degree, fit.RSquared, fit.RMS)
+		}
+		if diff := math.Abs(fit.Eval(1005.1) - math.Sin(5.1/3)); diff > 0.001 {
+			t.Fatalf("evaluation with degree %d off by %g", degree, diff)
+		}
+	}
+}
+
+// Error should be returned when DownhillSimplexOptions is given unusable
+// bounds or when its first search fails.
+func Test07(t *testing.T) {
+	fnc := func(x []float64) float64 {
+		return x[0]*x[0] + x[1]*x[1]
+	}
+	init := []float64{1, 1}
+	_, err := regression.DownhillSimplexOptions(fnc, init, regression.SimplexOptionsType{Lower: []float64{0}})
+	if err == nil {
+		t.Fatalf("expecting error with short bound list")
+	}
+	_, err = regression.DownhillSimplexOptions(fnc, init, regression.SimplexOptionsType{
+		Lower: []float64{0, 2}, Upper: []float64{1, 1}})
+	if err == nil {
+		t.Fatalf("expecting error with crossed bounds")
+	}
+	_, err = regression.DownhillSimplex(func(x []float64) float64 { return math.NaN() }, init, 0.1, 0)
+	if err == nil {
+		t.Fatalf("expecting error from failed search")
+	}
+}
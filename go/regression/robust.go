@@ -0,0 +1,297 @@
+package regression
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/jung-kurt/etc/go/util"
+	"gonum.org/v1/gonum/stat"
+)
+
+const (
+	cnMADScale      = 1.4826 // converts median absolute deviation to standard deviation for normal data
+	cnInlierSigma   = 2.5    // residuals within this many standard deviations are inliers
+	cnHuberK        = 1.345  // default Huber tuning constant, 95% efficient for normal data
+	cnHuberIter     = 50     // maximum number of reweighting iterations
+	cnRANSACIter    = 200    // default number of RANSAC samples
+	cnRANSACSeed    = 1      // seed used to make RANSAC results repeatable
+	cnRobustMinimum = 2      // minimum number of points for a line
+)
+
+// median returns the median of the values in list. The list is not modified.
+func median(list []float64) float64 {
+	count := len(list)
+	if count == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), list...)
+	sort.Float64s(sorted)
+	if count%2 == 1 {
+		return sorted[count/2]
+	}
+	return (sorted[count/2-1] + sorted[count/2]) / 2
+}
+
+// linearCheck returns an error if xList and yList cannot describe a line.
+func linearCheck(xList, yList []float64) (err error) {
+	if len(xList) != len(yList) {
+		err = errors.New("x and y lists differ in length")
+	} else if len(xList) < cnRobustMinimum {
+		err = errors.New("insufficient number of points for line fit")
+	} else {
+		var rng util.RangeType
+		for j, x := range xList {
+			rng.Set(x, j == 0)
+		}
+		if rng.Min == rng.Max {
+			err = errors.New("line fit requires distinct x values")
+		}
+	}
+	return
+}
+
+// linearResiduals returns the differences between yList and the line
+// specified by eq at the corresponding x values.
+func linearResiduals(xList, yList []float64, eq util.LinearEquationType) (resList []float64) {
+	resList = make([]float64, len(xList))
+	for j, x := range xList {
+		resList[j] = yList[j] - (eq.Slope*x + eq.Intercept)
+	}
+	return
+}
+
+// linearSubset returns the points for which inliers is true.
+func linearSubset(xList, yList []float64, inliers []bool) (xSub, ySub []float64) {
+	for j, ok := range inliers {
+		if ok {
+			xSub = append(xSub, xList[j])
+			ySub = append(ySub, yList[j])
+		}
+	}
+	return
+}
+
+// linearInliers marks the points that lie within cnInlierSigma robust
+// standard deviations of the line specified by eq, and then sets the
+// r-squared and RMS values of le from those points.
+func linearInliers(xList, yList []float64, le *LinearFitType) (inliers []bool) {
+	resList := linearResiduals(xList, yList, le.Eq)
+	absList := make([]float64, len(resList))
+	for j, r := range resList {
+		absList[j] = math.Abs(r)
+	}
+	lim := cnInlierSigma * cnMADScale * median(absList)
+	inliers = make([]bool, len(resList))
+	for j, r := range absList {
+		inliers[j] = r <= lim
+	}
+	linearStats(xList, yList, inliers, le)
+	return
+}
+
+// linearStats sets the r-squared and RMS values of le from the points for
+// which inliers is true.
+func linearStats(xList, yList []float64, inliers []bool, le *LinearFitType) {
+	xSub, ySub := linearSubset(xList, yList, inliers)
+	estList := make([]float64, len(xSub))
+	for j, x := range xSub {
+		estList[j] = le.Eq.Slope*x + le.Eq.Intercept
+	}
+	le.RSquared, le.RMS = fitStats(ySub, estList)
+}
+
+// LinearFitWeighted returns the slope, intercept, r-squared values, and RMS
+// value for the weighted least squares regression fit of the points specified
+// by xList and yList. Each point contributes to the fit in proportion to the
+// corresponding value in weights, for example the reciprocal of its variance.
+// The r-squared and RMS values are weighted in the same way. An error is
+// returned if weights does not hold a value for each point, if any weight is
+// negative, or if all weights are zero.
+func LinearFitWeighted(xList, yList, weights []float64) (le LinearFitType, err error) {
+	var sum, wSum float64
+	err = linearCheck(xList, yList)
+	if err == nil && len(weights) != len(xList) {
+		err = errors.New("weight and point lists differ in length")
+	}
+	if err == nil {
+		for _, w := range weights {
+			if !(w >= 0) {
+				err = errors.New("weights must not be negative")
+				break
+			}
+			wSum += w
+		}
+		if err == nil && wSum == 0 {
+			err = errors.New("at least one weight must be positive")
+		}
+	}
+	if err != nil {
+		return
+	}
+	le.Eq.Intercept, le.Eq.Slope = stat.LinearRegression(xList, yList, weights, false)
+	le.RSquared = stat.RSquared(xList, yList, weights, le.Eq.Intercept, le.Eq.Slope)
+	for j, x := range xList {
+		diff := yList[j] - (le.Eq.Slope*x + le.Eq.Intercept)
+		sum += weights[j] * diff * diff
+	}
+	le.RMS = math.Sqrt(sum / wSum)
+	return
+}
+
+// LinearFitTheilSen returns the Theil-Sen regression fit of the points
+// specified by xList and yList. The slope is the median of the slopes between
+// all pairs of points with distinct x values, and the intercept is the median
+// of the values y - slope * x. Up to 29% of the points can be arbitrarily
+// corrupted without affecting the line. inliers reports the points that lie
+// within 2.5 robust standard deviations of the line; the r-squared and RMS
+// values are calculated from these points only. The number of slopes grows
+// with the square of the number of points, so this method is best suited to
+// data sets of up to a few thousand points.
+func LinearFitTheilSen(xList, yList []float64) (le LinearFitType, inliers []bool, err error) {
+	err = linearCheck(xList, yList)
+	if err == nil {
+		var slopeList []float64
+		count := len(xList)
+		for j := 0; j < count; j++ {
+			for k := j + 1; k < count; k++ {
+				if dx := xList[k] - xList[j]; dx != 0 {
+					slopeList = append(slopeList, (yList[k]-yList[j])/dx)
+				}
+			}
+		}
+		le.Eq.Slope = median(slopeList)
+		interceptList := make([]float64, count)
+		for j, x := range xList {
+			interceptList[j] = yList[j] - le.Eq.Slope*x
+		}
+		le.Eq.Intercept = median(interceptList)
+		inliers = linearInliers(xList, yList, &le)
+	}
+	return
+}
+
+// LinearFitRANSAC returns the random sample consensus (RANSAC) regression fit
+// of the points specified by xList and yList. Lines through pairs of points
+// are evaluated, and the one with the most points within threshold (measured
+// vertically) is refit to those points by least squares. The number of pairs
+// tried is specified by iterations; if this is zero or negative, 200 is used.
+// If there are no more pairs than iterations, every pair is tried; otherwise
+// pairs are chosen at random with a fixed seed so that results are
+// repeatable. inliers reports the points within threshold of the final line;
+// the r-squared and RMS values are calculated from these points only. An
+// error is returned if threshold is not positive.
+func LinearFitRANSAC(xList, yList []float64, threshold float64, iterations int) (le LinearFitType, inliers []bool, err error) {
+	err = linearCheck(xList, yList)
+	if err == nil && threshold <= 0 {
+		err = errors.New("RANSAC threshold must be positive")
+	}
+	if err != nil {
+		return
+	}
+	count := len(xList)
+	if iterations <= 0 {
+		iterations = cnRANSACIter
+	}
+	mark := func(eq util.LinearEquationType) (list []bool, total int) {
+		list = make([]bool, count)
+		for j, r := range linearResiduals(xList, yList, eq) {
+			if math.Abs(r) <= threshold {
+				list[j] = true
+				total++
+			}
+		}
+		return
+	}
+	best := -1
+	try := func(j, k int) {
+		if xList[j] != xList[k] {
+			eq := util.Linear(xList[j], yList[j], xList[k], yList[k])
+			list, total := mark(eq)
+			if total > best {
+				best = total
+				inliers = list
+				le.Eq = eq
+			}
+		}
+	}
+	if count*(count-1)/2 <= iterations {
+		for j := 0; j < count; j++ {
+			for k := j + 1; k < count; k++ {
+				try(j, k)
+			}
+		}
+	} else {
+		rnd := rand.New(rand.NewSource(cnRANSACSeed))
+		for j := 0; j < iterations; j++ {
+			a := rnd.Intn(count)
+			b := rnd.Intn(count - 1)
+			if b >= a {
+				b++
+			}
+			try(a, b)
+		}
+	}
+	if best < 0 {
+		err = errors.New("RANSAC found no pair of points with distinct x values")
+		return
+	}
+	xSub, ySub := linearSubset(xList, yList, inliers)
+	if linearCheck(xSub, ySub) == nil {
+		le.Eq.Intercept, le.Eq.Slope = stat.LinearRegression(xSub, ySub, nil, false)
+		inliers, _ = mark(le.Eq)
+	}
+	linearStats(xList, yList, inliers, &le)
+	return
+}
+
+// LinearFitHuber returns the regression fit of the points specified by xList
+// and yList that minimizes the Huber loss, which is quadratic for small
+// residuals and linear for large ones so that outliers have limited
+// influence. The fit is found by iteratively reweighted least squares
+// starting from the Theil-Sen line. Residuals are scaled by a robust estimate
+// of their standard deviation, and the transition between quadratic and
+// linear loss occurs at k scaled units; if k is zero or negative, 1.345 is
+// used. inliers reports the points in the quadratic region of the final fit;
+// the r-squared and RMS values are calculated from these points only.
+func LinearFitHuber(xList, yList []float64, k float64) (le LinearFitType, inliers []bool, err error) {
+	le, _, err = LinearFitTheilSen(xList, yList)
+	if err != nil {
+		return
+	}
+	if k <= 0 {
+		k = cnHuberK
+	}
+	count := len(xList)
+	weights := make([]float64, count)
+	inliers = make([]bool, count)
+	absList := make([]float64, count)
+	for iter := 0; iter < cnHuberIter; iter++ {
+		resList := linearResiduals(xList, yList, le.Eq)
+		for j, r := range resList {
+			absList[j] = math.Abs(r)
+		}
+		lim := k * cnMADScale * median(absList)
+		for j, r := range absList {
+			inliers[j] = r <= lim
+			if inliers[j] {
+				weights[j] = 1
+			} else {
+				weights[j] = lim / r
+			}
+		}
+		if lim == 0 {
+			// More than half of the points lie on the line
+			break
+		}
+		prev := le.Eq
+		le.Eq.Intercept, le.Eq.Slope = stat.LinearRegression(xList, yList, weights, false)
+		if math.Abs(le.Eq.Slope-prev.Slope) <= 1e-12*(1+math.Abs(prev.Slope)) &&
+			math.Abs(le.Eq.Intercept-prev.Intercept) <= 1e-12*(1+math.Abs(prev.Intercept)) {
+			break
+		}
+	}
+	linearStats(xList, yList, inliers, &le)
+	return
+}
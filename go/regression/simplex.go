@@ -0,0 +1,230 @@
+package regression
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"time"
+
+	"gonum.org/v1/gonum/optimize"
+)
+
+const (
+	cnSpread   = 10   // restart spread, in initial simplex sizes, along unbounded dimensions
+	cnSizeInit = 0.05 // initial simplex size used when none is specified, as in gonum
+)
+
+// SimplexOptionsType controls the search performed by
+// DownhillSimplexOptions(). Length and Expansion correspond to the arguments
+// of DownhillSimplex(); zero values select the defaults of gonum's Nelder-Mead
+// method. Iterations, Evaluations and Runtime limit the total number of
+// simplex iterations, calls to the search function and elapsed time, over all
+// restarts; zero values impose no limit. Lower and Upper, if not nil, hold a
+// bound for each dimension; use math.Inf() for dimensions without one. Probes
+// outside the bounds are moved to the nearest point within them and penalized
+// in proportion to the squared distance, so the result always lies within the
+// bounds. Restarts specifies the number of additional searches started from
+// random points to escape local minima. Within bounds on both sides, starting
+// points are uniformly distributed; along other dimensions, they are normally
+// distributed about the best point found so far with a standard deviation of
+// ten simplex sizes. Seed initializes the random number generator so that
+// restarts are repeatable. If RepeatTolerance is positive, each search is
+// repeated from its own result with a fresh simplex until a repetition
+// improves the value by no more than RepeatTolerance relative to its
+// magnitude; this guards against the simplex collapsing before it reaches the
+// minimum. It does not change the point at which an individual search is
+// considered converged, which is left to gonum.
+type SimplexOptionsType struct {
+	Length          float64
+	Expansion       float64
+	RepeatTolerance float64
+	Iterations      int
+	Evaluations     int
+	Runtime         time.Duration
+	Lower           []float64
+	Upper           []float64
+	Restarts        int
+	Seed            int64
+}
+
+// SimplexResultType reports the outcome of DownhillSimplexOptions(). X is
+// the location of the lowest value found and F is that value. Iterations and
+// Evaluations are the total number of simplex iterations and calls to the
+// search function, Runtime is the elapsed time, and Searches is the number of
+// searches performed including restarts and repetitions. Status describes why
+// the final search ended, for example "FunctionConvergence" or
+// "IterationLimit".
+type SimplexResultType struct {
+	X           []float64
+	F           float64
+	Iterations  int
+	Evaluations int
+	Searches    int
+	Runtime     time.Duration
+	Status      string
+}
+
+// simplexLimited returns true if the search that produced r ended because it
+// reached a limit on iterations, evaluations or time.
+func simplexLimited(r *optimize.Result) bool {
+	if r == nil {
+		return false
+	}
+	switch r.Status {
+	case optimize.IterationLimit, optimize.FunctionEvaluationLimit, optimize.RuntimeLimit:
+		return true
+	}
+	return false
+}
+
+// simplexClamp returns x moved to the nearest point within the bounds
+// specified by lower and upper, and the squared distance that it moved.
+func simplexClamp(x, lower, upper []float64) (y []float64, dist float64) {
+	y = append([]float64(nil), x...)
+	for j := range y {
+		if lower != nil && y[j] < lower[j] {
+			dist += (lower[j] - y[j]) * (lower[j] - y[j])
+			y[j] = lower[j]
+		}
+		if upper != nil && y[j] > upper[j] {
+			dist += (y[j] - upper[j]) * (y[j] - upper[j])
+			y[j] = upper[j]
+		}
+	}
+	return
+}
+
+// DownhillSimplexOptions is like DownhillSimplex() but accepts the search
+// parameters, limits, bounds and restarts specified by opt, and reports the
+// final value and search statistics along with the location. See
+// SimplexOptionsType for details. An error is returned if the bounds do not
+// hold a value for each dimension, if a lower bound exceeds the corresponding
+// upper bound, or if the first search fails for a reason other than reaching
+// a limit. Failures of later searches do not invalidate the best result.
+func DownhillSimplexOptions(fnc func(x []float64) float64, init []float64, opt SimplexOptionsType) (res SimplexResultType, err error) {
+	var prb optimize.Problem
+	var r *optimize.Result
+	var settings *optimize.Settings
+	var firstErr error
+
+	for _, list := range [][]float64{opt.Lower, opt.Upper} {
+		if list != nil && len(list) != len(init) {
+			return res, errors.New("simplex bounds differ in length from initial point")
+		}
+	}
+	if opt.Lower != nil && opt.Upper != nil {
+		for j := range init {
+			if opt.Lower[j] > opt.Upper[j] {
+				return res, errors.New("simplex lower bound exceeds upper bound")
+			}
+		}
+	}
+	startTm := time.Now()
+	bounded := opt.Lower != nil || opt.Upper != nil
+	prb.Func = fnc
+	if bounded {
+		prb.Func = func(x []float64) float64 {
+			y, dist := simplexClamp(x, opt.Lower, opt.Upper)
+			val := fnc(y)
+			return val + dist*(1+math.Abs(val))
+		}
+	}
+	length := opt.Length
+	if length == 0 {
+		length = cnSizeInit
+	}
+	limited := opt.Iterations > 0 || opt.Evaluations > 0 || opt.Runtime > 0
+	// exhausted reports whether a limit has been reached, and otherwise sets
+	// the limits of the next search to what remains
+	exhausted := func() bool {
+		if !limited {
+			return false
+		}
+		settings = &optimize.Settings{}
+		if opt.Iterations > 0 {
+			settings.MajorIterations = opt.Iterations - res.Iterations
+			if settings.MajorIterations <= 0 {
+				return true
+			}
+		}
+		if opt.Evaluations > 0 {
+			settings.FuncEvaluations = opt.Evaluations - res.Evaluations
+			if settings.FuncEvaluations <= 0 {
+				return true
+			}
+		}
+		if opt.Runtime > 0 {
+			settings.Runtime = opt.Runtime - time.Since(startTm)
+			if settings.Runtime <= 0 {
+				return true
+			}
+		}
+		return false
+	}
+	search := func(x []float64) (ok bool) {
+		if exhausted() {
+			return false
+		}
+		first := res.Searches == 0
+		r, err = optimize.Local(prb, x, settings, &optimize.NelderMead{
+			SimplexSize: length,
+			Expansion:   opt.Expansion,
+		})
+		if r != nil {
+			res.Searches++
+			res.Iterations += r.Stats.MajorIterations
+			res.Evaluations += r.Stats.FuncEvaluations
+			res.Status = r.Status.String()
+			if res.X == nil || r.F < res.F {
+				res.X = append([]float64(nil), r.X...)
+				res.F = r.F
+			}
+		}
+		if err != nil && first && !simplexLimited(r) {
+			firstErr = err
+		}
+		return err == nil
+	}
+	// polish repeats a search from its own result while it keeps improving
+	polish := func(x []float64) (ok bool) {
+		ok = search(x)
+		for ok && opt.RepeatTolerance > 0 {
+			prev := r.F
+			ok = search(r.X)
+			if ok && prev-r.F <= opt.RepeatTolerance*math.Abs(prev) {
+				break
+			}
+		}
+		return
+	}
+	ok := polish(init)
+	if ok && opt.Restarts > 0 {
+		rnd := rand.New(rand.NewSource(opt.Seed))
+		for j := 0; ok && j < opt.Restarts; j++ {
+			x := make([]float64, len(init))
+			for k := range x {
+				if opt.Lower != nil && opt.Upper != nil &&
+					!math.IsInf(opt.Lower[k], 0) && !math.IsInf(opt.Upper[k], 0) {
+					x[k] = opt.Lower[k] + rnd.Float64()*(opt.Upper[k]-opt.Lower[k])
+				} else {
+					x[k] = res.X[k] + rnd.NormFloat64()*cnSpread*length
+				}
+			}
+			ok = polish(x)
+		}
+	}
+	if firstErr != nil {
+		err = firstErr
+	} else if res.X != nil {
+		// A search that ended at a limit or failed during a restart does not
+		// invalidate the best result; Status reports how the last one ended
+		err = nil
+		if bounded {
+			res.X, _ = simplexClamp(res.X, opt.Lower, opt.Upper)
+			res.F = fnc(res.X)
+			res.Evaluations++
+		}
+	}
+	res.Runtime = time.Since(startTm)
+	return
+}
@@ -1,3 +1,8 @@
+// Package util holds general-purpose helpers for strings, numbers, JSON,
+// logging, files and archives. The functions that measure the displayed width
+// of strings, such as StrWidth(), StrTruncate(), StrPad() and the dot leader
+// functions built on them, depend on github.com/mattn/go-runewidth; the rest
+// of the package uses only the standard library.
 package util
 
 import (
@@ -7,6 +12,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/mattn/go-runewidth"
 )
 
 // Address is used specify a network address. It includes JSON marshaling and
@@ -166,12 +173,43 @@ func StrIf(cond bool, aStr string, bStr string) string {
 	return bStr
 }
 
+// StrWidth returns the number of terminal columns used to display str. Wide
+// characters such as CJK ideographs and most emoji use two columns, and
+// combining marks use none.
+func StrWidth(str string) int {
+	return runewidth.StringWidth(str)
+}
+
+// StrTruncate returns str shortened, if necessary, so that it is displayed in
+// no more than wd columns. If str is shortened, tailStr (for example, "..")
+// is appended within that width. Characters are never split.
+func StrTruncate(str string, wd int, tailStr string) string {
+	if wd <= 0 {
+		return ""
+	}
+	if StrWidth(tailStr) > wd {
+		tailStr = ""
+	}
+	return runewidth.Truncate(str, wd, tailStr)
+}
+
+// StrPad returns str padded with blanks so that it is displayed in wd
+// columns. A negative value for wd pads on the left (right justifying str);
+// positive pads on the right. str is returned unchanged if it is already at
+// least as wide.
+func StrPad(str string, wd int) string {
+	if wd < 0 {
+		return runewidth.FillLeft(str, -wd)
+	}
+	return runewidth.FillRight(str, wd)
+}
+
 // StrDotPair fills in the blank space between two strings with dots. The total
 // length displayed is indicated by fullLen. The left and right strings are
 // specified by lfStr and rtStr respectively. At least two dots are shown,
 // even if this means the total length exceeds fullLen.
 func StrDotPair(fullLen int, lfStr, rtStr string) string {
-	dotLen := fullLen - len(lfStr) - len(rtStr)
+	dotLen := fullLen - StrWidth(lfStr) - StrWidth(rtStr)
 	if dotLen < 2 {
 		dotLen = 2
 	}
@@ -184,7 +222,7 @@ func StrDotPair(fullLen int, lfStr, rtStr string) string {
 // if this means the total length exceeds fullLen.
 func StrDotPairFormat(fullLen int, lfStr, rtFmtStr string, args ...interface{}) string {
 	rtStr := fmt.Sprintf(rtFmtStr, args...)
-	dotLen := fullLen - len(lfStr) - len(rtStr)
+	dotLen := fullLen - StrWidth(lfStr) - StrWidth(rtStr)
 	if dotLen < 2 {
 		dotLen = 2
 	}
@@ -194,16 +232,16 @@ func StrDotPairFormat(fullLen int, lfStr, rtFmtStr string, args ...interface{})
 // StrDots fills in blank spaces with dots. A negative value for fullLen
 // indicates a left justified string; positive indicates right. For example,
 // ("abc", -12) returns "abc ........", ("abc", 12) returns "........ abc". If
-// the length of str is longer than the absolute value of fullLen, it is
-// truncated to that value.
+// the width of str is greater than the absolute value of fullLen, it is
+// truncated to that value. Lengths are measured in terminal columns.
 func StrDots(str string, fullLen int) string {
 	left := fullLen < 0
 	if left {
 		fullLen = -fullLen
 	}
-	slen := len(str)
+	slen := StrWidth(str)
 	if slen > fullLen {
-		return str[:fullLen]
+		return StrTruncate(str, fullLen, "")
 	} else if slen < fullLen-1 {
 		dotStr := strings.Repeat(".", fullLen-slen-1)
 		if left {
@@ -342,9 +380,9 @@ func StrHeader(fullLen int, fill string, str string) (hdrStr string) {
 	if fill == "" {
 		fill = "-"
 	} else {
-		fill = fill[:1]
+		fill = string([]rune(fill)[:1])
 	}
-	ln = len(str)
+	ln = StrWidth(str)
 	if ln+4 < fullLen {
 		lfLen = (fullLen - ln) / 2
 		rtLen = fullLen - ln - lfLen
@@ -333,6 +333,20 @@ func ExampleStrDelimit() {
 	// G
 }
 
+// This example demonstrates alignment of strings that contain wide characters
+func ExampleStrTruncate() {
+	for _, str := range []string{"plain", "日本語テキスト", "e\u0301te\u0301"} {
+		fmt.Printf("[%s] [%s] %d\n", util.StrPad(util.StrTruncate(str, 8, ".."), 8),
+			util.StrPad(str, -6), util.StrWidth(str))
+	}
+	fmt.Println(util.StrDotPair(16, "東京", "42"))
+	// Output:
+	// [plain   ] [ plain] 5
+	// [日本語..] [日本語テキスト] 14
+	// [été     ] [   été] 3
+	// 東京..........42
+}
+
 // This example demonstrates the generic sorting function
 func ExampleSort() {
 	var list = []string{"red", "green", "blue"}